@@ -0,0 +1,95 @@
+package rd
+
+// Action is a semantic action. It computes the value of a non-terminal that exited
+// with a true result from the values of its subtrees, in order:
+//   - the value computed by their action for non-terminals with an action,
+//   - the subtree (a *TreeOf[T]) for non-terminals without one,
+//   - the token for tokens (type T),
+//   - the ErrorSymbol for non-terminals that recovered from a syntax error (see
+//     Builder's Sync method). Their actions aren't called.
+//
+// Actions must not have side effects: a non-terminal can be parsed, and its action
+// called, more than once while backtracking.
+type Action func(values []interface{}) interface{}
+
+// TypedAction returns an Action calling f, so that the type of the values it
+// computes is checked at compile time. ValueOf[V] then always succeeds for the
+// non-terminal's node, unless f returns a nil interface:
+//
+//	rd.Actions(map[interface{}]rd.Action{
+//		"Number": rd.TypedAction(func(values []interface{}) float64 { ... }),
+//	})
+func TypedAction[V any](f func(values []interface{}) V) Action {
+	return func(values []interface{}) interface{} {
+		return f(values)
+	}
+}
+
+// Actions attaches semantic actions to non-terminals, which makes the Builder
+// compute values while parsing. Non-terminals must be comparable. The value of a
+// non-terminal is stored in its parse tree node (see TreeOf's Value field), so the
+// value of the whole input is that of the parse tree:
+//
+//	b := rd.NewBuilder(tokens, rd.Actions(map[interface{}]rd.Action{
+//		"Expr": func(values []interface{}) interface{} { ... },
+//	}))
+//	if Expr(b) {
+//		expr, _ := rd.ValueOf[ast.Expr](b.ParseTree())
+//	}
+//
+// Once a non-terminal with an action has a value, its subtrees are discarded. Use
+// KeepParseTree to keep them.
+func Actions(actions map[interface{}]Action) Option {
+	return func(o *options) {
+		if o.actions == nil {
+			o.actions = map[interface{}]Action{}
+		}
+		for nonTerm, action := range actions {
+			o.actions[nonTerm] = action
+		}
+	}
+}
+
+// KeepParseTree keeps the subtrees of non-terminals with actions (see Actions), so
+// that the complete parse tree is available along with the values.
+func KeepParseTree() Option {
+	return func(o *options) {
+		o.keepParseTree = true
+	}
+}
+
+// ValueOf returns the value of t, computed by its non-terminal's action (see
+// Actions), as V. Values aren't converted: ok is false, and v is V's zero value,
+// if the action returned a value of another type (ex. an int for a float64 V) or
+// nil, or if t's non-terminal has no action (its value is then t itself).
+// Actions created using TypedAction can't return values of another type.
+func ValueOf[V any, T any](t *TreeOf[T]) (v V, ok bool) {
+	v, ok = t.Value.(V)
+	return
+}
+
+// act sets the value of t, a non-terminal that exited with a true result.
+func (b *BuilderOf[T]) act(t *TreeOf[T], recovered bool) {
+	t.valued = true
+	if recovered {
+		t.Value = t.Subtrees[0].Symbol
+		return
+	}
+	action, ok := b.actions[t.Symbol]
+	if !ok {
+		t.Value = t
+		return
+	}
+	values := make([]interface{}, len(t.Subtrees))
+	for i, st := range t.Subtrees {
+		if st.valued {
+			values[i] = st.Value
+		} else {
+			values[i] = st.Symbol
+		}
+	}
+	t.Value = action(values)
+	if !b.keepParseTree {
+		t.Subtrees = nil
+	}
+}
@@ -0,0 +1,159 @@
+package rd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActions_LeftRecursion(t *testing.T) {
+	// Expr = Expr "-" Num | Num
+	var expr func(b *Builder) bool
+	num := func(b *Builder) (ok bool) {
+		defer b.Enter("Num").Exit(&ok)
+		return b.MatchFunc(func(token Token) bool {
+			_, ok := token.(int)
+			return ok
+		}, "number")
+	}
+	expr = func(b *Builder) (ok bool) {
+		defer b.Enter("Expr").Exit(&ok)
+		return b.Memo(func() bool {
+			if expr(b) && b.Match("-") && num(b) {
+				return true
+			}
+			b.Backtrack()
+			return num(b)
+		})
+	}
+	actions := Actions(map[interface{}]Action{
+		"Expr": func(values []interface{}) interface{} {
+			if len(values) == 1 {
+				return values[0]
+			}
+			return values[0].(int) - values[2].(int)
+		},
+		"Num": func(values []interface{}) interface{} {
+			return values[0]
+		},
+	})
+	tokens := []Token{5, "-", 2, "-", 1}
+
+	b := NewBuilder(tokens, Memoize(), actions)
+	assert.True(t, expr(b))
+	value, ok := ValueOf[int](b.ParseTree())
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+	assert.Empty(t, b.ParseTree().Subtrees)
+	_, ok = ValueOf[string](b.ParseTree())
+	assert.False(t, ok)
+
+	b = NewBuilder(tokens, Memoize(), actions, KeepParseTree())
+	assert.True(t, expr(b))
+	tree := b.ParseTree()
+	assert.Equal(t, 2, tree.Value)
+	assert.Equal(t, 3, tree.Subtrees[0].Value)
+	assert.Equal(t, 5, tree.Subtrees[0].Subtrees[0].Value)
+	expected := `Expr
+├─ Expr
+│  ├─ Expr
+│  │  └─ Num
+│  │     └─ 5
+│  ├─ -
+│  └─ Num
+│     └─ 2
+├─ -
+└─ Num
+   └─ 1
+`
+	assert.Equal(t, expected, tree.String())
+}
+
+func TestActions_Values(t *testing.T) {
+	// Block = "begin" Stmt {";" Stmt} Empty "end"
+	// Stmt  = ident ":=" number
+	stmt := func(b *Builder) (ok bool) {
+		defer b.Enter("Stmt").Sync(";", "end").Exit(&ok)
+		ident, ok := b.Next()
+		if !ok {
+			return false
+		}
+		b.Add(ident)
+		return b.Match(":=") && b.Match("1")
+	}
+	empty := func(b *Builder) (ok bool) {
+		defer b.Enter("Empty").Exit(&ok)
+		b.Skip()
+		return true
+	}
+	block := func(b *Builder) (ok bool) {
+		defer b.Enter("Block").Exit(&ok)
+		if !b.Match("begin") || !stmt(b) {
+			return false
+		}
+		for b.Match(";") {
+			if !stmt(b) {
+				return false
+			}
+		}
+		return empty(b) && b.Match("end")
+	}
+
+	var values []interface{}
+	b := NewBuilder([]Token{"begin", "a", ":=", "1", ";", "b", "1", "end"}, Actions(map[interface{}]Action{
+		"Block": func(v []interface{}) interface{} {
+			values = v
+			return len(v)
+		},
+	}))
+	assert.True(t, block(b))
+	assert.Equal(t, 5, b.ParseTree().Value)
+	if assert.Len(t, values, 5) {
+		assert.Equal(t, "begin", values[0])
+		stmt := values[1].(*Tree)
+		assert.Equal(t, []Token{"a", ":=", "1"}, stmt.Tokens())
+		assert.Equal(t, stmt, stmt.Value, "non-terminals without actions are their own values")
+		assert.Equal(t, ";", values[2])
+		assert.Equal(t, ErrorSymbol{Err: b.Errs()[0].(*ParsingError)}, values[3])
+		assert.Equal(t, "end", values[4])
+	}
+}
+
+func TestTypedAction(t *testing.T) {
+	// Sum = Num "+" Num
+	num := func(b *Builder) (ok bool) {
+		defer b.Enter("Num").Exit(&ok)
+		return b.MatchFunc(func(token Token) bool {
+			_, ok := token.(int)
+			return ok
+		}, "number")
+	}
+	sum := func(b *Builder) (ok bool) {
+		defer b.Enter("Sum").Exit(&ok)
+		return num(b) && b.Match("+") && num(b)
+	}
+	actions := map[interface{}]Action{
+		"Sum": TypedAction(func(values []interface{}) float64 {
+			return values[0].(float64) + values[2].(float64)
+		}),
+		"Num": TypedAction(func(values []interface{}) float64 {
+			return float64(values[0].(int))
+		}),
+	}
+
+	b := NewBuilder([]Token{1, "+", 2}, Actions(actions))
+	assert.True(t, sum(b))
+	value, ok := ValueOf[float64](b.ParseTree())
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, value)
+	// values aren't converted
+	_, ok = ValueOf[int](b.ParseTree())
+	assert.False(t, ok)
+
+	// a nil interface isn't a value of the interface type
+	actions["Sum"] = TypedAction(func(values []interface{}) error { return nil })
+	b = NewBuilder([]Token{1, "+", 2}, Actions(actions))
+	assert.True(t, sum(b))
+	_, ok = ValueOf[error](b.ParseTree())
+	assert.False(t, ok)
+}
@@ -1,76 +1,120 @@
 package rd
 
 import (
-	"fmt"
 	"log"
 )
 
-// ParsingError is error returned by Builder's Err method in case an error occurs
-// during parsing.
-type ParsingError struct {
-	errString string
-}
-
-func (e *ParsingError) Error() string {
-	return e.errString
-}
-
-func newParsingError(errString string) *ParsingError {
-	return &ParsingError{errString: errString}
-}
-
 // Builder stores details about tokens, index to current token, etc. and provides
 // methods to build recursive descent parsers conveniently. It keeps a track of
 // entry/exit from non-terminal functions, and terminal matches done inside them.
 // Results from non-terminal function calls help create the parse tree. A debug
 // tree is also created to help trace flow across non-terminal functions.
-type Builder struct {
-	tokens         []Token
-	current        int
-	stack          stack
-	finalEle       ele
-	debugStack     debugStack
-	finalDebugTree *DebugTree
-	finalErr       *ParsingError
-	skip           bool
+//
+// Builder works with untyped tokens. See BuilderOf for a statically typed Builder.
+type Builder = BuilderOf[Token]
+
+// BuilderOf is a Builder for tokens of type T. Its methods take and return tokens
+// of type T, and it builds parse trees of type *TreeOf[T].
+type BuilderOf[T comparable] struct {
+	tokens        []T
+	current       int
+	stack         stack[T]
+	finalEle      ele[T]
+	tracers       []Tracer
+	debugTracer   *DebugTracer
+	finalErr      error
+	recoveredErrs ErrorList
+	skip          bool
+	memo          map[memoKey]*memoEntry[T]
+	furthest      int
+	furthestStack []interface{}
+	expected      []Token
+	actions       map[interface{}]Action
+	keepParseTree bool
+}
+
+type options struct {
+	memoize       bool
+	tracers       []Tracer
+	debug         debugMode
+	debugLast     int
+	actions       map[interface{}]Action
+	keepParseTree bool
+}
+
+// Option configures a Builder. Options are passed to NewBuilder.
+type Option func(*options)
+
+// Memoize enables memoization of non-terminal results (see Builder's Memo method).
+// It's helpful for backtracking grammars, which otherwise parse the same
+// non-terminal at the same index over and over again.
+func Memoize() Option {
+	return func(o *options) {
+		o.memoize = true
+	}
 }
 
 // NewBuilder returns a new Builder for the tokens.
-func NewBuilder(tokens []Token) *Builder {
-	return &Builder{
-		tokens:     tokens,
-		current:    -1,
-		stack:      stack{},
-		debugStack: debugStack{},
+func NewBuilder(tokens []Token, opts ...Option) *Builder {
+	return NewBuilderOf(tokens, opts...)
+}
+
+// NewBuilderOf returns a new BuilderOf for the tokens.
+func NewBuilderOf[T comparable](tokens []T, opts ...Option) *BuilderOf[T] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	b := &BuilderOf[T]{
+		tokens:        tokens,
+		current:       -1,
+		stack:         stack[T]{},
+		debugTracer:   newDebugTracer(o),
+		tracers:       o.tracers,
+		actions:       o.actions,
+		keepParseTree: o.keepParseTree,
+	}
+	if b.debugTracer != nil {
+		b.tracers = append([]Tracer{b.debugTracer}, o.tracers...)
 	}
+	if o.memoize {
+		b.memo = map[memoKey]*memoEntry[T]{}
+	}
+	return b
 }
 
 // Peek returns the ith token without updating the current index. i must be
 // relative to the current index.
 //
 // ex. if current index points to tkn3:
-//  tokens:           tkn1 tkn2 tkn3 tkn4 tkn5
-//  original indexes:  0    1    2    3    4
-//  relative indexes: -2   -1    0    1    2
+//
+//	tokens:           tkn1 tkn2 tkn3 tkn4 tkn5
+//	original indexes:  0    1    2    3    4
+//	relative indexes: -2   -1    0    1    2
+//
 // you can use:
-//  Peek(-2) to get tkn1,
-//  Peek(-1) to get tkn2,
-//  Peek(1) to get tkn4,
-//  Peek(2) to get tkn5.
+//
+//	Peek(-2) to get tkn1,
+//	Peek(-1) to get tkn2,
+//	Peek(1) to get tkn4,
+//	Peek(2) to get tkn5.
 //
 // ok is false if i lies outside original index range, else true.
-func (b *Builder) Peek(i int) (token Token, ok bool) {
+func (b *BuilderOf[T]) Peek(i int) (token T, ok bool) {
 	b.mustEnter("Peek")
 	j := b.current + i
-	if j < 0 || j >= len(b.tokens) {
-		return nil, false
+	if j >= 0 && j < len(b.tokens) {
+		token, ok = b.tokens[j], true
 	}
-	return b.tokens[j], true
+	for _, t := range b.tracers {
+		t.Peek(PeekEvent{Index: j, Token: boxToken(token, ok), OK: ok})
+	}
+	return token, ok
 }
 
 // Check is a convenience function over Peek. It calls Peek to check if returned
 // token is same as token, and returned ok is true.
-func (b *Builder) Check(token Token, i int) bool {
+func (b *BuilderOf[T]) Check(token T, i int) bool {
 	b.mustEnter("Check")
 	peekedToken, ok := b.Peek(i)
 	return peekedToken == token && ok
@@ -78,7 +122,7 @@ func (b *Builder) Check(token Token, i int) bool {
 
 // CheckOrNotOK is a convenience function over Peek. It calls Peek to check if
 // returned token is same as token, or returned ok is false.
-func (b *Builder) CheckOrNotOK(token Token, i int) bool {
+func (b *BuilderOf[T]) CheckOrNotOK(token T, i int) bool {
 	b.mustEnter("CheckOrNotOK")
 	peekedToken, ok := b.Peek(i)
 	return peekedToken == token || !ok
@@ -86,14 +130,20 @@ func (b *Builder) CheckOrNotOK(token Token, i int) bool {
 
 // Next increments the current index to return the next token. ok is false if
 // no tokens are left, else true.
-func (b *Builder) Next() (token Token, ok bool) {
+func (b *BuilderOf[T]) Next() (token T, ok bool) {
 	b.mustEnter("Next")
-	return b.next()
+	index := b.current + 1
+	b.reach(index)
+	token, ok = b.next()
+	for _, t := range b.tracers {
+		t.Next(NextEvent{Index: index, Token: boxToken(token, ok), OK: ok})
+	}
+	return token, ok
 }
 
-func (b *Builder) next() (token Token, ok bool) {
+func (b *BuilderOf[T]) next() (token T, ok bool) {
 	if b.current == len(b.tokens)-1 {
-		return nil, false
+		return token, false
 	}
 	b.current++
 	return b.tokens[b.current], true
@@ -102,19 +152,65 @@ func (b *Builder) next() (token Token, ok bool) {
 // Backtrack resets the current index for the non-terminal function it's called inside,
 // and sets it to the value it was before entering this function. It also discards any
 // matches done inside the function.
-func (b *Builder) Backtrack() {
+func (b *BuilderOf[T]) Backtrack() {
 	b.mustEnter("Backtrack")
 	e := b.stack.peek()
+	b.traceBacktrack(e, e.index, false)
 	b.current = e.index
-	e.nonTerm.Subtrees = []*Tree{}
+	e.nonTerm.Subtrees = []*TreeOf[T]{}
+	b.recoveredErrs = b.recoveredErrs[:e.errs]
+}
+
+func (b *BuilderOf[T]) traceBacktrack(e ele[T], to int, growSeed bool) {
+	for _, t := range b.tracers {
+		t.Backtrack(BacktrackEvent{NonTerm: e.nonTerm.Symbol, From: b.current + 1, To: to + 1, GrowSeed: growSeed})
+	}
+}
+
+// Mark is a position inside a non-terminal function. See Builder's Mark method.
+type Mark struct {
+	index    int
+	subtrees int
+	errs     int
+}
+
+// Mark returns the current position inside the non-terminal function it's called
+// inside: the current index, and the number of symbols added so far.
+func (b *BuilderOf[T]) Mark() Mark {
+	b.mustEnter("Mark")
+	return Mark{index: b.current, subtrees: len(b.stack.peek().nonTerm.Subtrees), errs: len(b.recoveredErrs)}
+}
+
+// Reset is a finer-grained Backtrack. It resets the current index to m, and
+// discards any matches done since m was returned by Mark, along with the errors
+// recovered from since. m must have been returned inside the same non-terminal
+// function.
+func (b *BuilderOf[T]) Reset(m Mark) {
+	b.mustEnter("Reset")
+	e := b.stack.peek()
+	b.traceBacktrack(e, m.index, false)
+	b.current = m.index
+	e.nonTerm.Subtrees = e.nonTerm.Subtrees[:m.subtrees:m.subtrees]
+	b.recoveredErrs = b.recoveredErrs[:m.errs]
 }
 
 // Add adds token as a symbol in the parse tree. It's added under the current
-// non-terminal subtree.
-func (b *Builder) Add(token Token) {
+// non-terminal subtree. Its span is that of the current token.
+func (b *BuilderOf[T]) Add(token T) {
 	b.mustEnter("Add")
+	for _, t := range b.tracers {
+		t.Add(AddEvent{Index: b.current, Token: token})
+	}
+	b.add(token)
+}
+
+func (b *BuilderOf[T]) add(token T) {
 	e := b.stack.peek()
-	e.nonTerm.Add(NewTree(token))
+	t := NewTreeOf[T](token)
+	if b.current >= 0 {
+		t.Span = b.span(b.current, b.current+1)
+	}
+	e.nonTerm.Add(t)
 }
 
 // Match matches the next token to token. In case of a non-match the current index
@@ -123,47 +219,206 @@ func (b *Builder) Add(token Token) {
 //
 // Internally Match calls Next to grab the next token. In case of a match it adds
 // it by calling Add. Debug info is also added to the debug tree.
-func (b *Builder) Match(token Token) (ok bool) {
+func (b *BuilderOf[T]) Match(token T) (ok bool) {
 	b.mustEnter("Match")
-	debugMsg := ""
-	defer func() {
-		dt := b.debugStack.peek()
-		dt.add(newDebugTree(debugMsg))
-	}()
+	return b.match(func(next T) bool { return next == token }, token)
+}
 
-	next, ok := b.Next()
-	if !ok {
-		debugMsg = fmt.Sprint("<no tokens left> ≠ ", token)
-		return false
-	}
-	if next != token {
+// MatchFunc is like Match, except that the next token matches if pred returns
+// true for it. label describes the tokens pred matches, ex. "identifier". It's
+// used in place of the expected token in the debug tree (as "<identifier>") and in
+// ParsingError's Expected (as a Label).
+func (b *BuilderOf[T]) MatchFunc(pred func(token T) bool, label string) (ok bool) {
+	b.mustEnter("MatchFunc")
+	return b.match(pred, Label(label))
+}
+
+// MatchKind is like Match, except that the next token matches if it implements
+// Kinder, and its kind is kind. kind is used as the label (see MatchFunc).
+func (b *BuilderOf[T]) MatchKind(kind string) (ok bool) {
+	b.mustEnter("MatchKind")
+	return b.match(func(next T) bool {
+		k, ok := Token(next).(Kinder)
+		return ok && k.Kind() == kind
+	}, Label(kind))
+}
+
+// match matches the next token using pred. want is the expected token or Label.
+func (b *BuilderOf[T]) match(pred func(next T) bool, want Token) (ok bool) {
+	index := b.current + 1
+	b.reach(index)
+	next, ok := b.next()
+	noTokens := !ok
+	if ok && !pred(next) {
 		b.current--
-		debugMsg = fmt.Sprint(next, " ≠ ", token)
-		return false
+		ok = false
 	}
-	b.Add(token)
-	debugMsg = fmt.Sprint(next, " = ", token)
-	return true
+	if ok {
+		b.add(next)
+	} else {
+		b.expect(index, want)
+	}
+	// events are only built if needed, since boxing tokens can allocate
+	if len(b.tracers) > 0 {
+		e := MatchEvent{Index: index, Token: boxToken(next, !noTokens), NoTokens: noTokens, Want: want, OK: ok}
+		for _, t := range b.tracers {
+			t.Match(e)
+		}
+	}
+	return ok
 }
 
 // Skip removes the current non-terminal from the parse tree regardless of the
 // exit result. It's helpful in case of null productions - where non-terminals
 // don't contribute to the parse tree.
-func (b *Builder) Skip() {
+func (b *BuilderOf[T]) Skip() {
 	b.skip = true
 }
 
+// Alternative notifies tracers that alternative alt of the current non-terminal was
+// tried with result ok (see AlternativeEvent), and returns ok. alt labels the
+// alternative, ex. with its EBNF. It's needed to tell apart alternatives that
+// start with the same token or non-terminal, ex. in coverage reports (see
+// Coverage):
+//
+//	if b.Alternative(`"(" Expr ")"`, b.Match("(") && Expr(b) && b.Match(")")) {
+//		return true
+//	}
+//	b.Backtrack()
+//	return b.Alternative("Number", Number(b))
+func (b *BuilderOf[T]) Alternative(alt string, ok bool) bool {
+	b.mustEnter("Alternative")
+	nonTerm := b.stack.peek().nonTerm.Symbol
+	for _, t := range b.tracers {
+		t.Alternative(AlternativeEvent{NonTerm: nonTerm, Alt: alt, OK: ok})
+	}
+	return ok
+}
+
+// Sync declares synchronization tokens for the current non-terminal, enabling
+// panic-mode error recovery for it. If the non-terminal exits with a false result,
+// Exit skips ahead from the furthest position reached (see Furthest) until one of
+// tokens is next. The parsing error is recorded (see Errs), the non-terminal's
+// subtree is replaced by an error node holding the skipped tokens, and the result
+// is set to true so that parsing continues. Recovery is abandoned if no token
+// would be skipped, or if none of tokens is found. The error is discarded along
+// with the error node if a non-terminal it's under fails, or backtracks past it.
+//
+// Sync should be called right after Enter:
+//
+//	defer b.Enter("Statement").Sync(Semicolon, End).Exit(&ok)
+func (b *BuilderOf[T]) Sync(tokens ...T) *BuilderOf[T] {
+	b.mustEnter("Sync")
+	b.stack.top().sync = tokens
+	return b
+}
+
+// Memo calls body and returns its result. body must contain the rest of the
+// current non-terminal function, right after Enter. If memoization is enabled
+// (see Memoize), the result is cached on Exit using the non-terminal and the
+// index it was entered at. A later call for the same non-terminal at the same
+// index returns the cached result without calling body, and Exit replays the
+// cached subtree and current index. Such calls are marked with "<memo hit>" in the
+// debug tree. Non-terminals must be comparable for memoization.
+//
+// ex.
+//
+//	func Term(b *rd.Builder) (ok bool) {
+//	    defer b.Enter("Term").Exit(&ok)
+//
+//	    return b.Memo(func() bool {
+//	        ...
+//	    })
+//	}
+//
+// Memoization also makes left-recursive non-terminals work, directly (Expr = Expr
+// "+" Term | Term) or indirectly through other non-terminals. A left-recursive call
+// first fails, and the shortest production found without it becomes a seed. body
+// is then called again and again, with the left-recursive call returning the
+// previous result, for as long as it consumes more tokens (Warth et al.'s seed
+// growing). This results in left-associative parse trees. Without memoization,
+// left-recursive non-terminals recurse forever.
+func (b *BuilderOf[T]) Memo(body func() bool) bool {
+	b.mustEnter("Memo")
+	if b.memo == nil {
+		return body()
+	}
+	e := b.stack.top()
+	key := memoKey{e.nonTerm.Symbol, e.index}
+	if m, ok := b.memo[key]; ok {
+		if m.inProgress {
+			b.involve(key)
+		}
+		e.hit = m
+		return m.result
+	}
+	seed := &memoEntry[T]{end: e.index, inProgress: true}
+	e.seed = seed
+	b.memo[key] = seed
+	result := body()
+	if !seed.leftRec {
+		return result
+	}
+
+	// e might have moved since body pushes on the stack
+	e = b.stack.top()
+	for result && b.current > seed.end {
+		seed.result, seed.end = true, b.current
+		seed.tree = &TreeOf[T]{Symbol: e.nonTerm.Symbol, Subtrees: e.nonTerm.Subtrees}
+		seed.errs = append(ErrorList{}, b.recoveredErrs[e.errs:]...)
+		if b.actions != nil {
+			b.act(seed.tree, false)
+		}
+		b.traceBacktrack(*e, e.index, true)
+		b.current = e.index
+		e.nonTerm.Subtrees = []*TreeOf[T]{}
+		b.recoveredErrs = b.recoveredErrs[:e.errs]
+		result = body()
+		e = b.stack.top()
+	}
+	b.current = seed.end
+	b.recoveredErrs = append(b.recoveredErrs[:e.errs], seed.errs...)
+	if seed.result {
+		e.nonTerm.Subtrees, e.nonTerm.Value, e.nonTerm.valued = seed.tree.Subtrees, seed.tree.Value, seed.tree.valued
+	} else {
+		e.nonTerm.Subtrees = []*TreeOf[T]{}
+	}
+	return seed.result
+}
+
+// involve marks the non-terminal at key, which is in progress, as left-recursive.
+// Non-terminals entered since it (including the current one) depend on its seed,
+// so their results must not be memoized.
+func (b *BuilderOf[T]) involve(key memoKey) {
+	b.memo[key].leftRec = true
+	for i := len(b.stack) - 1; i >= 0; i-- {
+		e := &b.stack[i]
+		if e.seed == b.memo[key] {
+			return
+		}
+		e.noMemo = true
+	}
+}
+
 // Enter adds non-terminal to the parse tree making it the current non-terminal.
 // Subsequent terminal matches and calls to non-terminal functions add symbols
 // under this non-terminal.
 //
 // Enter should be called right after entering the non-terminal function.
-func (b *Builder) Enter(nonTerm interface{}) *Builder {
-	b.stack.push(ele{
-		index:   b.current,
-		nonTerm: NewTree(nonTerm),
+func (b *BuilderOf[T]) Enter(nonTerm interface{}) *BuilderOf[T] {
+	return b.enter(nonTerm, b.current)
+}
+
+// enter enters nonTerm as if it had been entered with index as the current index.
+func (b *BuilderOf[T]) enter(nonTerm interface{}, index int) *BuilderOf[T] {
+	b.stack.push(ele[T]{
+		index:   index,
+		nonTerm: NewTreeOf[T](nonTerm),
+		errs:    len(b.recoveredErrs),
 	})
-	b.debugStack.push(newDebugTree(fmt.Sprint(nonTerm)))
+	for _, t := range b.tracers {
+		t.Enter(EnterEvent{NonTerm: nonTerm, Index: index + 1})
+	}
 	return b
 }
 
@@ -176,12 +431,37 @@ func (b *Builder) Enter(nonTerm interface{}) *Builder {
 //
 // The convenient way to call Exit is by using a named boolean return for the
 // non-terminal function, and passing it's address to a deferred Exit.
-func (b *Builder) Exit(result *bool) {
+func (b *BuilderOf[T]) Exit(result *bool) {
 	b.mustEnter("Exit")
 	if result == nil {
 		panic("Exit result cannot be nil")
 	}
 	e := b.stack.pop()
+	if e.hit != nil {
+		b.current = e.hit.end
+		b.skip = e.hit.skip
+		if e.hit.result {
+			e.nonTerm = e.hit.tree
+			b.recoveredErrs = append(b.recoveredErrs, e.hit.errs...)
+		}
+	}
+	skip := b.skip
+	recovered := false
+	if !*result || b.skip {
+		// errors recovered from inside discarded subtrees are discarded too
+		b.recoveredErrs = b.recoveredErrs[:e.errs]
+	}
+	if !*result && !b.skip && e.hit == nil && len(e.sync) > 0 {
+		recovered = b.recover(e)
+		*result = recovered
+	}
+	if *result {
+		e.nonTerm.Span = b.span(e.index+1, b.current+1)
+		// memo hits and grown left-recursive results already have a value
+		if b.actions != nil && !skip && !e.nonTerm.valued {
+			b.act(e.nonTerm, recovered)
+		}
+	}
 	resetCurrent := false
 	switch {
 	case b.skip:
@@ -189,7 +469,11 @@ func (b *Builder) Exit(result *bool) {
 		b.skip = false
 	case *result && b.stack.isEmpty():
 		if _, ok := b.next(); ok {
-			b.finalErr = newParsingError("not all tokens consumed")
+			b.finalErr = &NotConsumedError{
+				Index:    b.current,
+				Leftover: boxTokens(b.tokens[b.current:]),
+				NonTerm:  lastConsumer(e.nonTerm),
+			}
 		} else {
 			b.finalEle = e
 		}
@@ -197,8 +481,7 @@ func (b *Builder) Exit(result *bool) {
 		parent := b.stack.peek()
 		parent.nonTerm.Add(e.nonTerm)
 	case b.stack.isEmpty():
-		// TODO: add additional info to the error message
-		b.finalErr = newParsingError("parsing error")
+		b.finalErr = b.newParsingError()
 		resetCurrent = true
 	default:
 		resetCurrent = true
@@ -206,38 +489,184 @@ func (b *Builder) Exit(result *bool) {
 	if resetCurrent {
 		b.current = e.index
 	}
+	if e.seed != nil {
+		key := memoKey{e.nonTerm.Symbol, e.index}
+		if e.noMemo {
+			delete(b.memo, key)
+		} else {
+			m := &memoEntry[T]{result: *result, skip: skip, end: b.current, tree: e.nonTerm}
+			if *result {
+				m.errs = append(ErrorList{}, b.recoveredErrs[e.errs:]...)
+			}
+			b.memo[key] = m
+		}
+	}
 
-	dt := b.debugStack.pop()
-	dt.data += fmt.Sprintf("(%t)", *result)
-	if b.debugStack.isEmpty() {
-		b.finalDebugTree = dt
-	} else {
-		parent := b.debugStack.peek()
-		parent.add(dt)
+	exit := ExitEvent{
+		NonTerm:   e.nonTerm.Symbol,
+		Start:     e.index + 1,
+		End:       b.current + 1,
+		Result:    *result,
+		Skip:      skip,
+		Recovered: recovered,
+		MemoHit:   e.hit != nil,
+	}
+	for _, t := range b.tracers {
+		t.Exit(exit)
 	}
 }
 
+// Furthest returns the index of the furthest token examined by Match or Next
+// throughout parsing, along with the non-terminals that were active when it was
+// first reached (outermost first). Unlike the current index, it's not reset by
+// Backtrack or by failed non-terminals, which makes it a good approximation of
+// where a parsing error lies in backtracking grammars.
+func (b *BuilderOf[T]) Furthest() (index int, nonTerms []interface{}) {
+	return b.furthest, b.furthestStack
+}
+
 // ParseTree returns the parse tree. It's set after the root non-terminal exits with
 // true result. Returns nil otherwise.
-func (b *Builder) ParseTree() *Tree {
+func (b *BuilderOf[T]) ParseTree() *TreeOf[T] {
 	return b.finalEle.nonTerm
 }
 
 // DebugTree returns the debug tree which includes all matches and non-matches, and
 // non-terminal results (displayed in parentheses) captured throughout parsing. It
 // helps in tracing the parsing flow. It's set after the root non-terminal exits.
-// Returns nil otherwise.
-func (b *Builder) DebugTree() *DebugTree {
-	return b.finalDebugTree
+// Returns nil otherwise, or if disabled using NoDebugTree. See DebugFailures and
+// DebugLast for lighter debug trees.
+func (b *BuilderOf[T]) DebugTree() *DebugTree {
+	if b.debugTracer == nil {
+		return nil
+	}
+	return b.debugTracer.Tree()
+}
+
+// Err returns all parsing errors (see Errs) as an ErrorList. Returns nil if there
+// were none. Individual errors can be inspected using errors.As.
+func (b *BuilderOf[T]) Err() error {
+	return b.Errs().Err()
+}
+
+// Errs returns all parsing errors. These include errors recovered from using Sync,
+// followed by the final error. The final error is set after the root non-terminal
+// exits with a false result (a *ParsingError), or with a true result while some
+// tokens are left unconsumed (a *NotConsumedError).
+func (b *BuilderOf[T]) Errs() ErrorList {
+	if b.finalErr == nil {
+		return b.recoveredErrs
+	}
+	n := len(b.recoveredErrs)
+	return append(b.recoveredErrs[:n:n], b.finalErr)
 }
 
-// Err returns the parsing error. It's set after the root non-terminal exits with a
-// false result. Returns nil otherwise.
-func (b *Builder) Err() *ParsingError {
-	return b.finalErr
+// recover tries panic-mode error recovery for e, which has exited with a false
+// result. It reports whether recovery succeeded, in which case the current index
+// points right before the synchronization token.
+func (b *BuilderOf[T]) recover(e ele[T]) bool {
+	start := e.index + 1
+	i := start
+	if b.furthest > i {
+		i = b.furthest
+	}
+	for ; i < len(b.tokens); i++ {
+		if contains(e.sync, b.tokens[i]) {
+			break
+		}
+	}
+	if i == start || i >= len(b.tokens) {
+		return false
+	}
+
+	err := b.newParsingError()
+	b.recoveredErrs.Add(err)
+	errTree := NewTreeOf[T](ErrorSymbol{Err: err})
+	errTree.Span = b.span(start, i)
+	for j := start; j < i; j++ {
+		t := NewTreeOf[T](b.tokens[j])
+		t.Span = b.span(j, j+1)
+		errTree.Add(t)
+	}
+	e.nonTerm.Subtrees = []*TreeOf[T]{errTree}
+	b.current = i - 1
+	// later errors are looked for past the synchronization token
+	b.furthest, b.furthestStack, b.expected = i, nil, nil
+	return true
+}
+
+func contains[T comparable](tokens []T, token T) bool {
+	for _, t := range tokens {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
+
+// boxToken returns token as a Token, or nil if ok is false.
+func boxToken[T any](token T, ok bool) Token {
+	if !ok {
+		return nil
+	}
+	return token
+}
+
+func boxTokens[T any](tokens []T) []Token {
+	boxed := make([]Token, len(tokens))
+	for i, token := range tokens {
+		boxed[i] = token
+	}
+	return boxed
+}
+
+// span returns the Span for tokens in [start, end).
+func (b *BuilderOf[T]) span(start, end int) Span {
+	s := Span{Start: start, End: end}
+	if start < end {
+		if p, ok := Token(b.tokens[start]).(Positioner); ok {
+			s.StartPos = p.Pos()
+		}
+		if p, ok := Token(b.tokens[end-1]).(Positioner); ok {
+			s.EndPos = p.End()
+		}
+	} else if start < len(b.tokens) {
+		if p, ok := Token(b.tokens[start]).(Positioner); ok {
+			s.StartPos, s.EndPos = p.Pos(), p.Pos()
+		}
+	}
+	return s
+}
+
+// reach records that the token at index i was examined. Tokens expected at a
+// previous, closer index are forgotten.
+func (b *BuilderOf[T]) reach(i int) {
+	// furthestStack is nil until the first token is examined
+	if i > b.furthest || b.furthestStack == nil {
+		b.furthest = i
+		b.furthestStack = b.stack.nonTerms()
+		b.expected = nil
+	}
+}
+
+// expect records that a failed Match wanted token (or a Label) at index i.
+func (b *BuilderOf[T]) expect(i int, token Token) {
+	b.reach(i)
+	if i < b.furthest || contains(b.expected, token) {
+		return
+	}
+	b.expected = append(b.expected, token)
+}
+
+func (b *BuilderOf[T]) newParsingError() *ParsingError {
+	e := &ParsingError{Index: b.furthest, Expected: b.expected, NonTerms: b.furthestStack}
+	if b.furthest < len(b.tokens) {
+		e.Token = b.tokens[b.furthest]
+	}
+	return e
 }
 
-func (b Builder) mustEnter(operation string) {
+func (b BuilderOf[T]) mustEnter(operation string) {
 	if len(b.stack) == 0 {
 		log.Panicf("cannot %s. must Enter a non-terminal first", operation)
 	}
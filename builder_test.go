@@ -1,6 +1,7 @@
 package rd
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -17,14 +18,14 @@ func TestExit_NilResult(t *testing.T) {
 func TestExit_FinalEleAndDebugTree(t *testing.T) {
 	b := NewBuilder(nil)
 	b.Enter("root")
-	assert.Equal(t, ele{}, b.finalEle)
-	assert.Nil(t, b.finalDebugTree)
+	assert.Equal(t, ele[Token]{}, b.finalEle)
+	assert.Nil(t, b.debugTracer.tree)
 	root := b.stack.peek()
-	rootDebugTree := b.debugStack.peek()
+	rootDebugTree := b.debugTracer.stack.peek()
 	result := true
 	b.Exit(&result)
 	assert.Equal(t, root, b.finalEle)
-	assert.Equal(t, rootDebugTree, b.finalDebugTree)
+	assert.Equal(t, rootDebugTree, b.debugTracer.tree)
 }
 
 func TestExit_FinalErr(t *testing.T) {
@@ -40,14 +41,14 @@ func TestExit_AddToParent(t *testing.T) {
 	b := NewBuilder(nil)
 	b.Enter("root")
 	root := b.stack.peek()
-	rootDebugTree := b.debugStack.peek()
+	rootDebugTree := b.debugTracer.stack.peek()
 	b.Enter("child")
 	child := b.stack.peek()
-	childDebugTree := b.debugStack.peek()
+	childDebugTree := b.debugTracer.stack.peek()
 	result := true
 	b.Exit(&result)
 	assert.Contains(t, root.nonTerm.Subtrees, child.nonTerm)
-	assert.Contains(t, rootDebugTree.subtrees, childDebugTree)
+	assert.Contains(t, rootDebugTree.Subtrees, childDebugTree)
 }
 
 func TestExit_FalseResult(t *testing.T) {
@@ -59,3 +60,649 @@ func TestExit_FalseResult(t *testing.T) {
 	b.Exit(&result)
 	assert.Equal(t, 1, b.current, "current must be reset")
 }
+
+func TestExit_FinalErrPosition(t *testing.T) {
+	b := NewBuilder([]Token{"a", "c"})
+	b.Enter("root")
+	b.Match("a")
+	b.Match("b")
+	b.Match("d")
+	result := false
+	b.Exit(&result)
+	expected := &ParsingError{Index: 1, Token: "c", Expected: []Token{"b", "d"}, NonTerms: []interface{}{"root"}}
+	assert.Equal(t, expected, b.finalErr)
+}
+
+func TestFurthest_Backtrack(t *testing.T) {
+	b := NewBuilder([]Token{"a", "b", "c"})
+	b.Enter("root")
+	b.Enter("child")
+	b.Match("a")
+	b.Match("b")
+	b.Match("d")
+	b.Backtrack()
+	result := false
+	b.Exit(&result)
+	index, nonTerms := b.Furthest()
+	assert.Equal(t, -1, b.current)
+	assert.Equal(t, 2, index)
+	assert.Equal(t, []interface{}{"root", "child"}, nonTerms)
+}
+
+func TestParsingError_Error(t *testing.T) {
+	err := &ParsingError{Index: 42, Token: "end", Expected: []Token{"then", "do"}}
+	assert.Equal(t, "expected `then` or `do`, found `end` at token 42", err.Error())
+	err = &ParsingError{Index: 3, Expected: []Token{"+", "-", ")"}}
+	assert.Equal(t, "expected `+`, `-` or `)`, found end of input at token 3", err.Error())
+	err = &ParsingError{Index: 1, Token: ")"}
+	assert.Equal(t, "unexpected `)` at token 1", err.Error())
+	err = &ParsingError{Index: 1, Token: ")", NonTerms: []interface{}{"Expr", "Term", "Factor"}}
+	assert.Equal(t, "unexpected `)` at token 1 in Factor", err.Error())
+}
+
+func TestExit_NotConsumed(t *testing.T) {
+	b := NewBuilder([]Token{"1", "+", "2", ")"})
+	b.Enter("Expr")
+	b.Enter("Number")
+	b.Match("1")
+	result := true
+	b.Exit(&result)
+	b.Match("+")
+	b.Enter("Number")
+	b.Match("2")
+	b.Exit(&result)
+	b.Exit(&result)
+	var err *NotConsumedError
+	if assert.True(t, errors.As(b.Err(), &err)) {
+		assert.Equal(t, 3, err.Index)
+		assert.Equal(t, []Token{")"}, err.Leftover)
+		assert.Equal(t, "Number", err.NonTerm)
+		assert.Equal(t, "unexpected `)` after Number at token 3", err.Error())
+	}
+	assert.Nil(t, b.ParseTree())
+}
+
+type posToken struct {
+	value string
+	pos   Pos
+}
+
+func (t posToken) Pos() Pos {
+	return t.pos
+}
+
+func (t posToken) End() Pos {
+	return Pos{Offset: t.pos.Offset + len(t.value), Line: t.pos.Line, Column: t.pos.Column + len(t.value)}
+}
+
+func TestExit_Span(t *testing.T) {
+	a := posToken{"a", Pos{Offset: 0, Line: 1, Column: 1}}
+	bc := posToken{"bc", Pos{Offset: 2, Line: 1, Column: 3}}
+	b := NewBuilder([]Token{a, bc})
+	b.Enter("root")
+	b.Match(a)
+	b.Enter("child")
+	b.Match(bc)
+	result := true
+	b.Exit(&result)
+	b.Exit(&result)
+	root := b.ParseTree()
+	assert.Equal(t, Span{Start: 0, End: 2, StartPos: a.pos, EndPos: Pos{Offset: 4, Line: 1, Column: 5}}, root.Span)
+	assert.Equal(t, Span{Start: 1, End: 2, StartPos: bc.pos, EndPos: Pos{Offset: 4, Line: 1, Column: 5}}, root.Subtrees[1].Span)
+	assert.Equal(t, Span{Start: 0, End: 1, StartPos: a.pos, EndPos: Pos{Offset: 1, Line: 1, Column: 2}}, root.Subtrees[0].Span)
+}
+
+func TestSync_Recover(t *testing.T) {
+	// Block = "begin" Stmt {";" Stmt} "end"
+	// Stmt  = ident ":=" number
+	var stmt func(b *Builder) bool
+	stmt = func(b *Builder) (ok bool) {
+		defer b.Enter("Stmt").Sync(";", "end").Exit(&ok)
+		ident, ok := b.Next()
+		if !ok {
+			return false
+		}
+		b.Add(ident)
+		return b.Match(":=") && b.Match("1")
+	}
+	block := func(b *Builder) (ok bool) {
+		defer b.Enter("Block").Exit(&ok)
+		if !b.Match("begin") || !stmt(b) {
+			return false
+		}
+		for b.Match(";") {
+			if !stmt(b) {
+				return false
+			}
+		}
+		return b.Match("end")
+	}
+
+	tokens := []Token{"begin", "a", ":=", "1", ";", "b", "1", ";", "c", ":=", ";", "d", ":=", "1", "end"}
+	b := NewBuilder(tokens)
+	assert.True(t, block(b))
+	errs := b.Errs()
+	if assert.Len(t, errs, 2) {
+		assert.Equal(t, "expected `:=`, found `1` at token 6 in Stmt", errs[0].Error())
+		assert.Equal(t, "expected `1`, found `;` at token 10 in Stmt", errs[1].Error())
+	}
+	assert.Equal(t, errs, b.Err())
+
+	expectedParseTree := `Block
+├─ begin
+├─ Stmt
+│  ├─ a
+│  ├─ :=
+│  └─ 1
+├─ ;
+├─ Stmt
+│  └─ <error>
+│     ├─ b
+│     └─ 1
+├─ ;
+├─ Stmt
+│  └─ <error>
+│     ├─ c
+│     └─ :=
+├─ ;
+├─ Stmt
+│  ├─ d
+│  ├─ :=
+│  └─ 1
+└─ end
+`
+	assert.Equal(t, expectedParseTree, b.ParseTree().String())
+	assert.Equal(t, Span{Start: 5, End: 7}, b.ParseTree().Subtrees[3].Subtrees[0].Span)
+}
+
+func TestSync_Backtrack(t *testing.T) {
+	// S = A "end" | B
+	// A = "x" "y" ";"
+	// B = "x" "z" ";"
+	parseA := func(b *Builder) (ok bool) {
+		defer b.Enter("A").Sync(";").Exit(&ok)
+		return b.Match("x") && b.Match("y") && b.Match(";")
+	}
+	parseB := func(b *Builder) (ok bool) {
+		defer b.Enter("B").Exit(&ok)
+		return b.Match("x") && b.Match("z") && b.Match(";")
+	}
+	parseS := func(b *Builder) (ok bool) {
+		defer b.Enter("S").Exit(&ok)
+		if parseA(b) && b.Match("end") {
+			return true
+		}
+		b.Backtrack()
+		return parseB(b)
+	}
+	tokens := []Token{"x", "z", ";"}
+
+	b := NewBuilder(tokens)
+	assert.True(t, parseS(b))
+	assert.Nil(t, b.Err(), "errors recovered from inside A are discarded with it")
+	assert.Equal(t, "S\n└─ B\n   ├─ x\n   ├─ z\n   └─ ;\n", b.ParseTree().String())
+
+	// same with Reset, and with A failing after recovering
+	b = NewBuilder(tokens)
+	func() (ok bool) {
+		defer b.Enter("S").Exit(&ok)
+		m := b.Mark()
+		func() (ok bool) {
+			defer b.Enter("S'").Exit(&ok)
+			return parseA(b) && b.Match("end")
+		}()
+		assert.Len(t, b.Errs(), 0)
+		parseA(b)
+		assert.Len(t, b.Errs(), 1)
+		b.Reset(m)
+		return parseB(b)
+	}()
+	assert.Nil(t, b.Err())
+}
+
+func TestSync_MemoHit(t *testing.T) {
+	// S = A "!" | A ";"
+	// A = "x" "y" with recovery at ";"
+	parseA := func(b *Builder) (ok bool) {
+		defer b.Enter("A").Sync(";").Exit(&ok)
+		return b.Memo(func() bool {
+			return b.Match("x") && b.Match("y")
+		})
+	}
+	parseS := func(b *Builder) (ok bool) {
+		defer b.Enter("S").Exit(&ok)
+		if parseA(b) && b.Match("!") {
+			return true
+		}
+		b.Backtrack()
+		return parseA(b) && b.Match(";")
+	}
+
+	b := NewBuilder([]Token{"x", "z", ";"}, Memoize())
+	assert.True(t, parseS(b))
+	assert.EqualError(t, b.Err(), "expected `y`, found `z` at token 1 in A")
+}
+
+func TestSync_NoSyncToken(t *testing.T) {
+	b := NewBuilder([]Token{"a", "b"})
+	b.Enter("root").Sync(";")
+	b.Match("b")
+	result := false
+	b.Exit(&result)
+	assert.False(t, result)
+	assert.Len(t, b.Errs(), 1)
+}
+
+func TestErrorList(t *testing.T) {
+	var l ErrorList
+	assert.Nil(t, l.Err())
+	l.Add(&ParsingError{Index: 5, Token: "x"})
+	l.Add(&NotConsumedError{Index: 2, Leftover: []Token{")"}})
+	l.Add(&ParsingError{Index: 5, Token: "y"})
+	l.Add(errors.New("other"))
+	l.Add(&ParsingError{Index: 1, Token: "z"})
+	assert.Equal(t, "unexpected `x` at token 5 (and 4 more errors)", l.Error())
+
+	l.RemoveMultiples()
+	assert.Equal(t, []int{1, 2, 5}, []int{l[0].(*ParsingError).Index, l[1].(*NotConsumedError).Index, l[2].(*ParsingError).Index})
+	assert.Equal(t, "x", l[2].(*ParsingError).Token)
+	assert.EqualError(t, l[3], "other")
+
+	l.Truncate(2)
+	assert.Len(t, l, 2)
+	var notConsumed *NotConsumedError
+	assert.True(t, errors.As(l.Err(), &notConsumed))
+}
+
+func TestMemo(t *testing.T) {
+	// S = A "x" | A "y"
+	// A = "a"
+	calls := 0
+	a := func(b *Builder) (ok bool) {
+		defer b.Enter("A").Exit(&ok)
+		return b.Memo(func() bool {
+			calls++
+			return b.Match("a")
+		})
+	}
+	s := func(b *Builder) (ok bool) {
+		defer b.Enter("S").Exit(&ok)
+		if a(b) && b.Match("x") {
+			return true
+		}
+		b.Backtrack()
+		return a(b) && b.Match("y")
+	}
+
+	b := NewBuilder([]Token{"a", "y"}, Memoize())
+	assert.True(t, s(b))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "S\n├─ A\n│  └─ a\n└─ y\n", b.ParseTree().String())
+	expectedDebugTree := `S(true)
+├─ A(true)
+│  └─ a = a
+├─ y ≠ x
+├─ A(true)
+│  └─ <memo hit>
+└─ y = y
+`
+	assert.Equal(t, expectedDebugTree, b.DebugTree().String())
+
+	calls = 0
+	b = NewBuilder([]Token{"a", "y"})
+	assert.True(t, s(b))
+	assert.Equal(t, 2, calls)
+}
+
+func TestMemo_LeftRecursion(t *testing.T) {
+	// E = E "-" N | N
+	// N = "1" | "2" | "3"
+	n := func(b *Builder) (ok bool) {
+		defer b.Enter("N").Exit(&ok)
+		return b.Match("1") || b.Match("2") || b.Match("3")
+	}
+	var e func(b *Builder) bool
+	e = func(b *Builder) (ok bool) {
+		defer b.Enter("E").Exit(&ok)
+		return b.Memo(func() bool {
+			if e(b) && b.Match("-") && n(b) {
+				return true
+			}
+			b.Backtrack()
+			return n(b)
+		})
+	}
+
+	b := NewBuilder([]Token{"1", "-", "2", "-", "3"}, Memoize())
+	assert.True(t, e(b))
+	assert.NoError(t, b.Err())
+	expectedParseTree := `E
+├─ E
+│  ├─ E
+│  │  └─ N
+│  │     └─ 1
+│  ├─ -
+│  └─ N
+│     └─ 2
+├─ -
+└─ N
+   └─ 3
+`
+	assert.Equal(t, expectedParseTree, b.ParseTree().String())
+	assert.Equal(t, Span{Start: 0, End: 3}, b.ParseTree().Subtrees[0].Span)
+}
+
+func TestMemo_IndirectLeftRecursion(t *testing.T) {
+	// A = B "x" | "y"
+	// B = A "z"
+	var a, bb func(b *Builder) bool
+	a = func(b *Builder) (ok bool) {
+		defer b.Enter("A").Exit(&ok)
+		return b.Memo(func() bool {
+			if bb(b) && b.Match("x") {
+				return true
+			}
+			b.Backtrack()
+			return b.Match("y")
+		})
+	}
+	bb = func(b *Builder) (ok bool) {
+		defer b.Enter("B").Exit(&ok)
+		return b.Memo(func() bool {
+			return a(b) && b.Match("z")
+		})
+	}
+
+	b := NewBuilder([]Token{"y", "z", "x", "z", "x"}, Memoize())
+	assert.True(t, a(b))
+	assert.NoError(t, b.Err())
+	expectedParseTree := `A
+├─ B
+│  ├─ A
+│  │  ├─ B
+│  │  │  ├─ A
+│  │  │  │  └─ y
+│  │  │  └─ z
+│  │  └─ x
+│  └─ z
+└─ x
+`
+	assert.Equal(t, expectedParseTree, b.ParseTree().String())
+}
+
+type kind int
+
+const (
+	num kind = iota
+	plus
+)
+
+func TestBuilderOf(t *testing.T) {
+	// S = num plus num
+	s := func(b *BuilderOf[kind]) (ok bool) {
+		defer b.Enter("S").Exit(&ok)
+		return b.Match(num) && b.Match(plus) && b.Match(num)
+	}
+
+	b := NewBuilderOf([]kind{num, plus, num})
+	assert.True(t, s(b))
+	tree := b.ParseTree()
+	_, ok := tree.Token()
+	assert.False(t, ok, "non-terminal must not be a token")
+	token, ok := tree.Subtrees[1].Token()
+	assert.True(t, ok)
+	assert.Equal(t, plus, token)
+
+	b = NewBuilderOf([]kind{num, num})
+	assert.False(t, s(b))
+	assert.EqualError(t, b.Err(), "expected `1`, found `0` at token 1 in S")
+}
+
+type kindToken struct {
+	kind, value string
+}
+
+func (t kindToken) Kind() string {
+	return t.kind
+}
+
+func (t kindToken) String() string {
+	return t.value
+}
+
+func TestMatchFunc(t *testing.T) {
+	isDigit := func(token Token) bool {
+		s, ok := token.(string)
+		return ok && len(s) == 1 && s[0] >= '0' && s[0] <= '9'
+	}
+	b := NewBuilder([]Token{"1", "x"})
+	b.Enter("root")
+	assert.True(t, b.MatchFunc(isDigit, "digit"))
+	assert.False(t, b.MatchFunc(isDigit, "digit"))
+	assert.False(t, b.Match("+"))
+	result := false
+	b.Exit(&result)
+	assert.Equal(t, "root(false)\n├─ 1 = <digit>\n├─ x ≠ <digit>\n└─ x ≠ +\n", b.DebugTree().String())
+	assert.EqualError(t, b.Err(), "expected <digit> or `+`, found `x` at token 1 in root")
+}
+
+func TestMatchKind(t *testing.T) {
+	x := kindToken{"identifier", "x"}
+	b := NewBuilder([]Token{x, kindToken{"number", "1"}})
+	b.Enter("root")
+	assert.True(t, b.MatchKind("identifier"))
+	assert.False(t, b.MatchKind("identifier"))
+	assert.True(t, b.MatchKind("number"))
+	assert.False(t, b.MatchKind("number"))
+	result := true
+	b.Exit(&result)
+	assert.Equal(t, x, b.ParseTree().Subtrees[0].Symbol)
+	assert.Equal(t, "root(true)\n├─ x = <identifier>\n├─ 1 ≠ <identifier>\n├─ 1 = <number>\n└─ <no tokens left> ≠ <number>\n", b.DebugTree().String())
+}
+
+func TestReset(t *testing.T) {
+	b := NewBuilder([]Token{"a", "b", "c"})
+	b.Enter("root")
+	b.Match("a")
+	m := b.Mark()
+	b.Match("b")
+	b.Match("c")
+	b.Reset(m)
+	assert.Equal(t, 0, b.current)
+	assert.Len(t, b.stack.peek().nonTerm.Subtrees, 1)
+	assert.True(t, b.Match("b"))
+}
+
+type recordingTracer struct {
+	events []interface{}
+}
+
+func (r *recordingTracer) Enter(e EnterEvent)             { r.events = append(r.events, e) }
+func (r *recordingTracer) Exit(e ExitEvent)               { r.events = append(r.events, e) }
+func (r *recordingTracer) Match(e MatchEvent)             { r.events = append(r.events, e) }
+func (r *recordingTracer) Next(e NextEvent)               { r.events = append(r.events, e) }
+func (r *recordingTracer) Peek(e PeekEvent)               { r.events = append(r.events, e) }
+func (r *recordingTracer) Backtrack(e BacktrackEvent)     { r.events = append(r.events, e) }
+func (r *recordingTracer) Add(e AddEvent)                 { r.events = append(r.events, e) }
+func (r *recordingTracer) Alternative(e AlternativeEvent) { r.events = append(r.events, e) }
+
+func TestTrace(t *testing.T) {
+	r := &recordingTracer{}
+	b := NewBuilder([]Token{"a", "b"}, Trace(r))
+	func() (ok bool) {
+		defer b.Enter("root").Exit(&ok)
+		b.Peek(1)
+		b.Match("a")
+		func() (ok bool) {
+			defer b.Enter("child").Exit(&ok)
+			next, _ := b.Next()
+			b.Add(next)
+			b.Backtrack()
+			return false
+		}()
+		b.Match("c")
+		return b.Match("b")
+	}()
+
+	assert.Equal(t, []interface{}{
+		EnterEvent{NonTerm: "root", Index: 0},
+		PeekEvent{Index: 0, Token: "a", OK: true},
+		MatchEvent{Index: 0, Token: "a", Want: "a", OK: true},
+		EnterEvent{NonTerm: "child", Index: 1},
+		NextEvent{Index: 1, Token: "b", OK: true},
+		AddEvent{Index: 1, Token: "b"},
+		BacktrackEvent{NonTerm: "child", From: 2, To: 1},
+		ExitEvent{NonTerm: "child", Start: 1, End: 1},
+		MatchEvent{Index: 1, Token: "b", Want: "c"},
+		MatchEvent{Index: 1, Token: "b", Want: "b", OK: true},
+		ExitEvent{NonTerm: "root", Start: 0, End: 2, Result: true},
+	}, r.events)
+}
+
+func TestTrace_NoTokensAndMemo(t *testing.T) {
+	r := &recordingTracer{}
+	b := NewBuilder([]Token{"a"}, Trace(r), Memoize())
+	child := func() (ok bool) {
+		defer b.Enter("child").Exit(&ok)
+		return b.Memo(func() bool {
+			return b.Match("a")
+		})
+	}
+	func() (ok bool) {
+		defer b.Enter("root").Exit(&ok)
+		m := b.Mark()
+		b.Match("x")
+		child()
+		b.Reset(m)
+		child()
+		return !b.Match("a")
+	}()
+
+	assert.Contains(t, r.events, MatchEvent{Index: 1, NoTokens: true, Want: "a"})
+	assert.Contains(t, r.events, ExitEvent{NonTerm: "child", Start: 0, End: 1, Result: true, MemoHit: true})
+	assert.Contains(t, r.events, BacktrackEvent{NonTerm: "root", From: 1, To: 0})
+}
+
+func TestDebugTree_Events(t *testing.T) {
+	b := NewBuilder([]Token{"a"})
+	func() (ok bool) {
+		defer b.Enter("root").Exit(&ok)
+		return b.Match("a")
+	}()
+
+	dt := b.DebugTree()
+	assert.Equal(t, ExitEvent{NonTerm: "root", Start: 0, End: 1, Result: true}, dt.Event)
+	assert.Equal(t, MatchEvent{Index: 0, Token: "a", Want: "a", OK: true}, dt.Subtrees[0].Event)
+	assert.Equal(t, "root(true)\n└─ a = a\n", dt.String())
+}
+
+func TestNoDebugTree(t *testing.T) {
+	r := &recordingTracer{}
+	b := NewBuilder([]Token{"x"}, NoDebugTree(), Trace(r))
+	assert.True(t, benchExpr(b))
+	assert.Nil(t, b.DebugTree())
+	assert.NotEmpty(t, r.events)
+}
+
+func TestDebugFailures(t *testing.T) {
+	b := NewBuilder([]Token{"x", "+", "(", "x", "+", "y", ")"}, DebugFailures())
+	assert.False(t, benchExpr(b))
+	expectedDebugTree := `Expr(false)
+├─ Term(true)
+├─ + = +
+└─ Term(false)
+   ├─ ( = (
+   └─ Expr(false)
+      ├─ Term(true)
+      ├─ + = +
+      └─ Term(false)
+         ├─ y ≠ (
+         └─ y ≠ x
+`
+	assert.Equal(t, expectedDebugTree, b.DebugTree().String())
+
+	b = NewBuilder([]Token{"x"}, DebugFailures())
+	assert.True(t, benchExpr(b))
+	assert.Equal(t, "Expr(true)\n", b.DebugTree().String())
+}
+
+func TestDebugLast(t *testing.T) {
+	b := NewBuilder([]Token{"x", "+", "(", "x", ")"}, DebugLast(4))
+	assert.Nil(t, b.DebugTree())
+	func() (ok bool) {
+		defer b.Enter("Expr").Exit(&ok)
+		b.Match("x")
+		b.Match("+")
+		assert.Equal(t, "<last 3 events>\n└─ Expr\n   ├─ x = x\n   └─ + = +\n", b.DebugTree().String())
+		return benchTerm(b)
+	}()
+	expectedDebugTree := `<last 4 events>
+└─ Expr(true)
+   └─ Term(true)
+      ├─ Expr(true)
+      └─ ) = )
+`
+	assert.Equal(t, expectedDebugTree, b.DebugTree().String())
+	assert.Panics(t, func() { DebugLast(0) })
+}
+
+// benchTokens returns tokens for x+(x+x)+(x+x)+... with n parenthesized terms.
+func benchTokens(n int) []Token {
+	tokens := []Token{"x"}
+	for i := 0; i < n; i++ {
+		tokens = append(tokens, "+", "(", "x", "+", "x", ")")
+	}
+	return tokens
+}
+
+// benchExpr parses Expr = Term {"+" Term} . Term = "(" Expr ")" | "x" .
+func benchExpr(b *Builder) (ok bool) {
+	defer b.Enter("Expr").Exit(&ok)
+
+	if !benchTerm(b) {
+		return false
+	}
+	for b.Match("+") {
+		if !benchTerm(b) {
+			return false
+		}
+	}
+	return true
+}
+
+func benchTerm(b *Builder) (ok bool) {
+	defer b.Enter("Term").Exit(&ok)
+
+	if b.Match("(") {
+		return benchExpr(b) && b.Match(")")
+	}
+	return b.Match("x")
+}
+
+func benchmarkParse(bm *testing.B, opts ...Option) {
+	tokens := benchTokens(200)
+	bm.ReportAllocs()
+	bm.ResetTimer()
+	for i := 0; i < bm.N; i++ {
+		b := NewBuilder(tokens, opts...)
+		if !benchExpr(b) {
+			bm.Fatal(b.Err())
+		}
+	}
+}
+
+func BenchmarkParse(bm *testing.B) {
+	benchmarkParse(bm)
+}
+
+func BenchmarkParse_NoDebugTree(bm *testing.B) {
+	benchmarkParse(bm, NoDebugTree())
+}
+
+func BenchmarkParse_DebugFailures(bm *testing.B) {
+	benchmarkParse(bm, DebugFailures())
+}
+
+func BenchmarkParse_DebugLast(bm *testing.B) {
+	benchmarkParse(bm, DebugLast(100))
+}
@@ -0,0 +1,96 @@
+// Command rdcheck analyzes EBNF grammars (see package ebnf for the syntax) and
+// reports LL(1) conflicts, unreachable alternatives, left recursion, and
+// unreachable or unproductive rules. It exits with status 1 if issues are found.
+//
+// Usage:
+//
+//	rdcheck [flags] grammar.ebnf...
+//
+// ex. for arithmetic.ebnf:
+//
+//	Expr = Expr "+" Term | Term .
+//	Term = "(" Expr ")" | Number .
+//
+// rdcheck -sets arithmetic.ebnf prints:
+//
+//	arithmetic.ebnf:1:1: Expr: left recursion: Expr → Expr
+//	arithmetic.ebnf:1:1: Expr: FIRST/FIRST conflict: alternatives Expr "+" Term and Term can both start with "(", Number
+//	RULE  NULLABLE  FIRST          FOLLOW
+//	Expr  false     {"(", Number}  {")", "+", $}
+//	Term  false     {"(", Number}  {")", "+", $}
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/shivamMg/rd/ebnf"
+	"github.com/shivamMg/rd/ebnf/analysis"
+)
+
+var (
+	start = flag.String("start", "", "start rule. defaults to the first rule")
+	sets  = flag.Bool("sets", false, "print nullable rules, and FIRST and FOLLOW sets")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: rdcheck [flags] grammar.ebnf...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	status := 0
+	for _, file := range flag.Args() {
+		issues, err := check(os.Stdout, file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "rdcheck:", err)
+			os.Exit(1)
+		}
+		if issues {
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
+
+// check analyzes the grammar in file, and writes issues found to w. It reports
+// whether there were any.
+func check(w io.Writer, file string) (issues bool, err error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	g, err := ebnf.Parse(string(src))
+	if err != nil {
+		return false, fmt.Errorf("%s:%v", file, err)
+	}
+	if *start != "" && g.Rule(*start) == nil {
+		return false, fmt.Errorf("%s: undefined start rule %s", file, *start)
+	}
+
+	a := analysis.Analyze(g, *start)
+	for _, issue := range a.Issues {
+		fmt.Fprintf(w, "%s:%v\n", file, issue)
+	}
+	if *sets {
+		printSets(w, a)
+	}
+	return len(a.Issues) > 0, nil
+}
+
+func printSets(w io.Writer, a *analysis.Analysis) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tNULLABLE\tFIRST\tFOLLOW")
+	for _, r := range a.Grammar.Rules {
+		fmt.Fprintf(tw, "%s\t%t\t%v\t%v\n", r.Name, a.Nullable[r.Name], a.First[r.Name], a.Follow[r.Name])
+	}
+	tw.Flush()
+}
@@ -0,0 +1,78 @@
+// Command rddiagram renders the rules of an EBNF grammar (see package ebnf for
+// the syntax) as railroad diagrams, on a self-contained HTML page. Boxes for
+// rules link to their diagrams.
+//
+// Usage:
+//
+//	rddiagram [flags] grammar.ebnf
+//
+// ex.
+//
+//	rddiagram -title PL/0 -o pl0.html pl0.ebnf
+//	rddiagram -rule statement -o statement.svg pl0.ebnf
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/shivamMg/rd/ebnf"
+	"github.com/shivamMg/rd/ebnf/railroad"
+)
+
+var (
+	output = flag.String("o", "", "output file. defaults to stdout")
+	title  = flag.String("title", "", "page title. defaults to the grammar's file name")
+	rule   = flag.String("rule", "", "write only the diagram for rule, as an SVG image")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: rddiagram [flags] grammar.ebnf")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, "rddiagram:", err)
+		os.Exit(1)
+	}
+}
+
+func run(grammarFile string) error {
+	src, err := os.ReadFile(grammarFile)
+	if err != nil {
+		return err
+	}
+	g, err := ebnf.Parse(string(src))
+	if err != nil {
+		return fmt.Errorf("%s:%v", grammarFile, err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if *rule != "" {
+		r := g.Rule(*rule)
+		if r == nil {
+			return fmt.Errorf("undefined rule %s", *rule)
+		}
+		return railroad.SVG(w, g, r, nil)
+	}
+	if *title == "" {
+		*title = filepath.Base(grammarFile)
+	}
+	return railroad.HTML(w, g, *title)
+}
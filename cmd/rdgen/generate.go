@@ -0,0 +1,443 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/shivamMg/rd/ebnf"
+	"github.com/shivamMg/rd/ebnf/analysis"
+)
+
+const rdImport = `"github.com/shivamMg/rd"`
+
+// generator generates a parser for a grammar. Generated functions behave like
+// ebnf.Interpreter: alternatives are tried in order, options and repetitions
+// match as much as they can, and a rule that matches without consuming tokens
+// is skipped. The start rule's function is never skipped though, even where other
+// rules reference it, since it can't tell whether it's the root, and a skipped
+// root leaves Parse without a parse tree or an error.
+type generator struct {
+	grammar *ebnf.Grammar
+	mapping *mapping
+	// source is the grammar's file name, mentioned in the generated header.
+	source string
+	pkg    string
+	// start is the rule Parse starts with.
+	start string
+	// export capitalizes function names of rules not in mapping.
+	export bool
+	// memo wraps rule bodies in Memo, and makes Parse memoize.
+	memo bool
+
+	analysis *analysis.Analysis
+}
+
+// generate returns the parser's source. If old isn't nil, it's the source of a
+// previously generated and since hand-edited parser: only rule skeletons (doc
+// comments and signatures) are regenerated, and existing rule bodies, other
+// declarations and imports are preserved. Rules new to the grammar get
+// generated bodies.
+func (g *generator) generate(old []byte) ([]byte, error) {
+	g.analysis = analysis.Analyze(g.grammar, g.start)
+	var prev *oldFile
+	if old != nil {
+		var err error
+		if prev, err = g.parseOldFile(old); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if prev == nil {
+		fmt.Fprintf(&buf, "// Code generated by rdgen from %s. DO NOT EDIT.\n\n", g.source)
+	}
+	pkg := g.pkg
+	if pkg == "" && prev != nil {
+		pkg = prev.pkg
+	}
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+
+	imports := []string{rdImport}
+	imports = append(imports, g.mapping.imports...)
+	if prev != nil {
+		imports = append(imports, prev.imports...)
+	}
+	buf.WriteString("import (\n")
+	seen := map[string]bool{}
+	for _, spec := range imports {
+		if !seen[spec] {
+			seen[spec] = true
+			fmt.Fprintf(&buf, "\t%s\n", spec)
+		}
+	}
+	buf.WriteString(")\n")
+
+	if prev != nil {
+		for _, decl := range prev.before {
+			fmt.Fprintf(&buf, "\n%s\n", decl)
+		}
+	}
+	if prev == nil || !prev.hasParse {
+		g.writeParse(&buf)
+	}
+	for _, r := range g.grammar.Rules {
+		body, ok := "", false
+		if prev != nil {
+			body, ok = prev.bodies[g.funcName(r.Name)]
+		}
+		if !ok {
+			body = g.ruleBody(r)
+		}
+		fmt.Fprintf(&buf, "\n// %s\nfunc %s(b *rd.Builder) (ok bool) {%s}\n", r, g.funcName(r.Name), body)
+	}
+	if prev != nil {
+		for _, decl := range prev.after {
+			fmt.Fprintf(&buf, "\n%s\n", decl)
+		}
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return buf.Bytes(), fmt.Errorf("formatting generated source: %v", err)
+	}
+	return src, nil
+}
+
+func (g *generator) writeParse(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "\n// Parse parses tokens starting with %s.\n", g.start)
+	buf.WriteString("func Parse(tokens []rd.Token, opts ...rd.Option) (parseTree *rd.Tree, debugTree *rd.DebugTree, err error) {\n")
+	if g.memo {
+		buf.WriteString("b := rd.NewBuilder(tokens, append([]rd.Option{rd.Memoize()}, opts...)...)\n")
+	} else {
+		buf.WriteString("b := rd.NewBuilder(tokens, opts...)\n")
+	}
+	fmt.Fprintf(buf, "%s(b)\n", g.funcName(g.start))
+	buf.WriteString("return b.ParseTree(), b.DebugTree(), b.Err()\n}\n")
+}
+
+// ruleBody returns the body of r's function, braces excluded.
+func (g *generator) ruleBody(r *ebnf.Rule) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\ndefer b.Enter(%s).Exit(&ok)\n", strconv.Quote(r.Name))
+	if g.analysis.Nullable[r.Name] && r.Name != g.start {
+		b.WriteString("start := b.Mark()\n")
+		b.WriteString("defer func() {\nif ok && b.Mark() == start {\nb.Skip()\n}\n}()\n")
+	}
+	b.WriteString("\n")
+	if g.memo {
+		fmt.Fprintf(&b, "return b.Memo(func() bool {\n%s})\n", g.stmts(r.Expr))
+	} else {
+		b.WriteString(g.stmts(r.Expr))
+	}
+	return b.String()
+}
+
+// stmts returns statements matching e, that return the result. It's used for
+// rule bodies, where a false result needn't restore the current index and the
+// parse tree, as Exit does that.
+func (g *generator) stmts(e ebnf.Expr) string {
+	var b strings.Builder
+	switch e := e.(type) {
+	case ebnf.Alternation:
+		if g.atomic(e) {
+			break
+		}
+		for _, alt := range e[:len(e)-1] {
+			fmt.Fprintf(&b, "if %s {\nreturn true\n}\nb.Backtrack()\n", g.cond(alt))
+		}
+		fmt.Fprintf(&b, "return %s\n", g.cond(e[len(e)-1]))
+		return b.String()
+	case ebnf.Sequence:
+		var chunk []ebnf.Expr
+		for _, item := range e {
+			switch item := item.(type) {
+			case ebnf.Option:
+				b.WriteString(g.failIf(chunk))
+				b.WriteString(g.option(item))
+				chunk = nil
+			case ebnf.Repetition:
+				b.WriteString(g.failIf(chunk))
+				b.WriteString(g.repetition(item))
+				chunk = nil
+			case ebnf.Empty:
+			default:
+				chunk = append(chunk, item)
+			}
+		}
+		if len(chunk) == 0 {
+			b.WriteString("return true\n")
+		} else {
+			fmt.Fprintf(&b, "return %s\n", g.cond(ebnf.Sequence(chunk)))
+		}
+		return b.String()
+	case ebnf.Group:
+		return g.stmts(e.Expr)
+	case ebnf.Option, ebnf.Repetition:
+		return g.stmts(ebnf.Sequence{e})
+	}
+	fmt.Fprintf(&b, "return %s\n", g.cond(e))
+	return b.String()
+}
+
+// failIf returns a statement returning false if seq doesn't match.
+func (g *generator) failIf(seq []ebnf.Expr) string {
+	if len(seq) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("if %s {\nreturn false\n}\n", not(g.cond(ebnf.Sequence(seq))))
+}
+
+func (g *generator) option(o ebnf.Option) string {
+	switch o.Expr.(type) {
+	case ebnf.Terminal, ebnf.NonTerminal:
+		return g.cond(o.Expr) + "\n"
+	}
+	return fmt.Sprintf("if m := b.Mark(); %s {\nb.Reset(m)\n}\n", not(g.cond(o.Expr)))
+}
+
+// repetition returns a loop matching r.
+func (g *generator) repetition(r ebnf.Repetition) string {
+	if g.atomic(r.Expr) && !g.isNullable(r.Expr) {
+		return fmt.Sprintf("for %s {\n}\n", g.cond(r.Expr))
+	}
+	stop := not(g.cond(r.Expr))
+	if g.isNullable(r.Expr) {
+		// stop once no progress is made
+		stop += " || b.Mark() == m"
+	}
+	return fmt.Sprintf("for {\nm := b.Mark()\nif %s {\nb.Reset(m)\nbreak\n}\n}\n", stop)
+}
+
+// cond returns a boolean expression matching e. If e isn't atomic, tokens might
+// have been consumed when the expression is false.
+func (g *generator) cond(e ebnf.Expr) string {
+	switch e := e.(type) {
+	case ebnf.Terminal:
+		return "b.Match(" + g.terminal(string(e)) + ")"
+	case ebnf.NonTerminal:
+		return g.funcName(string(e)) + "(b)"
+	case ebnf.Empty:
+		return "true"
+	case ebnf.Group:
+		return g.cond(e.Expr)
+	case ebnf.Sequence:
+		var conds []string
+		for _, item := range e {
+			if _, ok := item.(ebnf.Empty); ok {
+				continue
+			}
+			c := g.cond(item)
+			if _, ok := unparen(item).(ebnf.Alternation); ok && g.atomic(item) {
+				c = "(" + c + ")"
+			}
+			conds = append(conds, c)
+		}
+		if len(conds) == 0 {
+			return "true"
+		}
+		return strings.Join(conds, " && ")
+	case ebnf.Alternation:
+		if g.atomic(e) {
+			conds := make([]string, len(e))
+			for i, alt := range e {
+				conds[i] = g.cond(alt)
+			}
+			return strings.Join(conds, " || ")
+		}
+		var b strings.Builder
+		b.WriteString("func() bool {\nm := b.Mark()\n")
+		for _, alt := range e[:len(e)-1] {
+			fmt.Fprintf(&b, "if %s {\nreturn true\n}\nb.Reset(m)\n", g.cond(alt))
+		}
+		fmt.Fprintf(&b, "return %s\n}()", g.cond(e[len(e)-1]))
+		return b.String()
+	case ebnf.Option:
+		if g.atomic(e.Expr) {
+			return "(" + g.cond(e.Expr) + " || true)"
+		}
+		return fmt.Sprintf("func() bool {\n%sreturn true\n}()", g.option(e))
+	case ebnf.Repetition:
+		return fmt.Sprintf("func() bool {\n%sreturn true\n}()", g.repetition(e))
+	}
+	panic(fmt.Sprintf("rdgen: unknown expression %T", e))
+}
+
+// atomic reports whether e is matched by a boolean expression that doesn't
+// consume tokens when it's false.
+func (g *generator) atomic(e ebnf.Expr) bool {
+	switch e := e.(type) {
+	case ebnf.Terminal, ebnf.NonTerminal, ebnf.Empty:
+		return true
+	case ebnf.Group:
+		return g.atomic(e.Expr)
+	case ebnf.Alternation:
+		for _, alt := range e {
+			if !g.atomic(alt) {
+				return false
+			}
+		}
+		return true
+	case ebnf.Sequence:
+		return len(e) == 1 && g.atomic(e[0])
+	}
+	return false
+}
+
+func unparen(e ebnf.Expr) ebnf.Expr {
+	for {
+		g, ok := e.(ebnf.Group)
+		if !ok {
+			return e
+		}
+		e = g.Expr
+	}
+}
+
+// not returns the negation of cond.
+func not(cond string) string {
+	if strings.HasSuffix(cond, ")") && !strings.Contains(cond, " ") {
+		return "!" + cond
+	}
+	return "!(" + cond + ")"
+}
+
+func (g *generator) terminal(t string) string {
+	if expr, ok := g.mapping.terminals[t]; ok {
+		return expr
+	}
+	return strconv.Quote(t)
+}
+
+// funcName returns the name of the function for rule. Characters that can't be
+// in Go identifiers are dropped: a ' becomes Prime, and a letter after a - is
+// capitalized.
+func (g *generator) funcName(rule string) string {
+	if name, ok := g.mapping.rules[rule]; ok {
+		return name
+	}
+	var b strings.Builder
+	upper := g.export
+	for _, r := range rule {
+		switch {
+		case r == '\'':
+			b.WriteString("Prime")
+		case r == '-':
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (g *generator) isNullable(e ebnf.Expr) bool {
+	return g.analysis.IsNullable(e)
+}
+
+func isIdent(s string) bool {
+	for i, r := range s {
+		if !(unicode.IsLetter(r) || r == '_' || i > 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != "" && token.Lookup(s) == token.IDENT
+}
+
+// oldFile is what's preserved from a previously generated file in skeleton mode.
+type oldFile struct {
+	pkg     string
+	imports []string
+	// bodies of functions, braces excluded, by function name
+	bodies map[string]string
+	// declarations other than functions for the grammar's rules, before and
+	// after the first function for a rule
+	before, after []string
+	hasParse      bool
+}
+
+func (g *generator) parseOldFile(src []byte) (*oldFile, error) {
+	ruleFuncs := map[string]bool{}
+	for _, r := range g.grammar.Rules {
+		ruleFuncs[g.funcName(r.Name)] = true
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	text := func(from, to token.Pos) string {
+		return string(src[fset.Position(from).Offset:fset.Position(to).Offset])
+	}
+
+	old := &oldFile{pkg: f.Name.Name, bodies: map[string]string{}}
+	for _, spec := range f.Imports {
+		old.imports = append(old.imports, text(spec.Pos(), spec.End()))
+	}
+	seenRule := false
+	for _, decl := range f.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.IMPORT {
+			continue
+		}
+		if d, ok := decl.(*ast.FuncDecl); ok && d.Recv == nil && d.Body != nil && ruleFuncs[d.Name.Name] && isRuleFunc(d) {
+			old.bodies[d.Name.Name] = text(d.Body.Lbrace+1, d.Body.Rbrace)
+			seenRule = true
+			continue
+		}
+		if d, ok := decl.(*ast.FuncDecl); ok && d.Recv == nil && d.Name.Name == "Parse" {
+			old.hasParse = true
+		}
+		from := decl.Pos()
+		if doc := docOf(decl); doc != nil {
+			from = doc.Pos()
+		}
+		if seenRule {
+			old.after = append(old.after, text(from, decl.End()))
+		} else {
+			old.before = append(old.before, text(from, decl.End()))
+		}
+	}
+	return old, nil
+}
+
+// isRuleFunc reports whether d has the signature of a rule's function:
+// func(b *rd.Builder) (ok bool).
+func isRuleFunc(d *ast.FuncDecl) bool {
+	params, results := d.Type.Params.List, d.Type.Results
+	if len(params) != 1 || len(params[0].Names) != 1 || results == nil || len(results.List) != 1 {
+		return false
+	}
+	star, ok := params[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Builder" {
+		return false
+	}
+	if x, ok := sel.X.(*ast.Ident); !ok || x.Name != "rd" {
+		return false
+	}
+	result, ok := results.List[0].Type.(*ast.Ident)
+	return ok && result.Name == "bool"
+}
+
+func docOf(decl ast.Decl) *ast.CommentGroup {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	}
+	return nil
+}
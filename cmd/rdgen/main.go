@@ -0,0 +1,148 @@
+// Command rdgen generates a parser using rd.Builder from an EBNF grammar (see
+// package ebnf for the syntax). The parser has a function per rule, and a Parse
+// function:
+//
+//	// Expr = Term "+" Expr | Term .
+//	func Expr(b *rd.Builder) (ok bool) {
+//		defer b.Enter("Expr").Exit(&ok)
+//
+//		if Term(b) && b.Match(Plus) && Expr(b) {
+//			return true
+//		}
+//		b.Backtrack()
+//		return Term(b)
+//	}
+//
+// Generated functions match the grammar like ebnf.Interpreter does. Rules that
+// aren't defined in the grammar must be defined in the same package, with the
+// same signature.
+//
+// Usage:
+//
+//	rdgen [flags] grammar.ebnf
+//
+// The token mapping file passed as -tokens maps terminals to Go expressions
+// passed to Match, and rule names to function names. Unmapped terminals are
+// matched as strings. ex.
+//
+//	import . "github.com/shivamMg/rd/examples/pl0/tokens"
+//
+//	":="    Assignment
+//	"begin" Begin
+//	ident   Ident
+//
+// With -skeleton, an existing output file is updated instead of overwritten: doc
+// comments and signatures of rule functions are regenerated from the grammar,
+// but their bodies, as well as other declarations, are preserved. Functions for
+// new rules are generated in full. This allows hand-editing a generated parser
+// (ex. to add Sync calls or Go code for semantic actions) while keeping it in
+// sync with its grammar.
+//
+// rdgen works with go generate, which sets the package name:
+//
+//	//go:generate rdgen -tokens tokens.txt -o parser.go grammar.ebnf
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shivamMg/rd/ebnf"
+)
+
+var (
+	output   = flag.String("o", "", "output file. defaults to stdout")
+	pkg      = flag.String("pkg", "", "package name. defaults to $GOPACKAGE, the output file's package, or parser")
+	tokens   = flag.String("tokens", "", "token mapping file")
+	start    = flag.String("start", "", "rule Parse starts with. defaults to the first rule")
+	export   = flag.Bool("export", false, "capitalize function names of rules")
+	memo     = flag.Bool("memo", false, "memoize rules, which allows left recursion")
+	skeleton = flag.Bool("skeleton", false, "update the output file, preserving bodies of existing rule functions")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: rdgen [flags] grammar.ebnf")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, "rdgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(grammarFile string) error {
+	src, err := os.ReadFile(grammarFile)
+	if err != nil {
+		return err
+	}
+	grammar, err := ebnf.Parse(string(src))
+	if err != nil {
+		return fmt.Errorf("%s:%v", grammarFile, err)
+	}
+	if len(grammar.Rules) == 0 {
+		return fmt.Errorf("%s: no rules", grammarFile)
+	}
+
+	g := &generator{
+		grammar: grammar,
+		mapping: &mapping{},
+		source:  filepath.Base(grammarFile),
+		pkg:     *pkg,
+		start:   *start,
+		export:  *export,
+		memo:    *memo,
+	}
+	if *tokens != "" {
+		f, err := os.Open(*tokens)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if g.mapping, err = parseMapping(f); err != nil {
+			return fmt.Errorf("%s: %v", *tokens, err)
+		}
+	}
+	if g.pkg == "" {
+		g.pkg = os.Getenv("GOPACKAGE")
+	}
+	if g.start == "" {
+		g.start = grammar.Rules[0].Name
+	} else if grammar.Rule(g.start) == nil {
+		return fmt.Errorf("undefined start rule %s", g.start)
+	}
+
+	var old []byte
+	if *skeleton {
+		if *output == "" {
+			return fmt.Errorf("-skeleton requires -o")
+		}
+		if old, err = os.ReadFile(*output); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if g.pkg == "" && old == nil {
+		g.pkg = "parser"
+	}
+
+	out, err := g.generate(old)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	if bytes.Equal(out, old) {
+		return nil
+	}
+	return os.WriteFile(*output, out, 0644)
+}
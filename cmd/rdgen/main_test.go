@@ -0,0 +1,242 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shivamMg/rd/ebnf"
+	"github.com/stretchr/testify/assert"
+)
+
+func newGenerator(t *testing.T, grammar, tokens string) *generator {
+	m, err := parseMapping(strings.NewReader(tokens))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	g := ebnf.MustParse(grammar)
+	return &generator{grammar: g, mapping: m, source: "grammar.ebnf", pkg: "parser", start: g.Rules[0].Name}
+}
+
+func TestGenerate(t *testing.T) {
+	g := newGenerator(t, `
+		List = Item { ("," | ";") Item } .
+		Item = ["-"] (number | "(" List ")") | ε .
+	`, `
+		"-" Minus
+		number Number
+	`)
+	src, err := g.generate(nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	expected := `// Code generated by rdgen from grammar.ebnf. DO NOT EDIT.
+
+package parser
+
+import (
+	"github.com/shivamMg/rd"
+)
+
+// Parse parses tokens starting with List.
+func Parse(tokens []rd.Token, opts ...rd.Option) (parseTree *rd.Tree, debugTree *rd.DebugTree, err error) {
+	b := rd.NewBuilder(tokens, opts...)
+	List(b)
+	return b.ParseTree(), b.DebugTree(), b.Err()
+}
+
+// List = Item {("," | ";") Item} .
+func List(b *rd.Builder) (ok bool) {
+	defer b.Enter("List").Exit(&ok)
+
+	if !Item(b) {
+		return false
+	}
+	for {
+		m := b.Mark()
+		if !((b.Match(",") || b.Match(";")) && Item(b)) {
+			b.Reset(m)
+			break
+		}
+	}
+	return true
+}
+
+// Item = ["-"] (number | "(" List ")") | ε .
+func Item(b *rd.Builder) (ok bool) {
+	defer b.Enter("Item").Exit(&ok)
+	start := b.Mark()
+	defer func() {
+		if ok && b.Mark() == start {
+			b.Skip()
+		}
+	}()
+
+	if (b.Match(Minus) || true) && func() bool {
+		m := b.Mark()
+		if Number(b) {
+			return true
+		}
+		b.Reset(m)
+		return b.Match("(") && List(b) && b.Match(")")
+	}() {
+		return true
+	}
+	b.Backtrack()
+	return true
+}
+`
+	assert.Equal(t, expected, string(src))
+}
+
+func TestGenerate_NullableStart(t *testing.T) {
+	g := newGenerator(t, `
+		S = {A} .
+		A = "a" [B] .
+		B = {"b"} .
+	`, ``)
+	src, err := g.generate(nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// the start rule is the root, so it's never skipped
+	assert.Contains(t, string(src), `func S(b *rd.Builder) (ok bool) {
+	defer b.Enter("S").Exit(&ok)
+
+	for A(b) {
+	}
+	return true
+}`)
+	assert.Contains(t, string(src), "func B(b *rd.Builder) (ok bool) {\n\tdefer b.Enter(\"B\").Exit(&ok)\n\tstart := b.Mark()\n")
+	assert.Equal(t, 1, strings.Count(string(src), "b.Skip()"))
+}
+
+func TestGenerate_Example(t *testing.T) {
+	dir := "../../examples/arithmetic/generatedparser/"
+	grammar, err := os.ReadFile(dir + "grammar.ebnf")
+	if !assert.NoError(t, err) {
+		return
+	}
+	tokens, err := os.ReadFile(dir + "tokens.txt")
+	if !assert.NoError(t, err) {
+		return
+	}
+	expected, err := os.ReadFile(dir + "parser.go")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	g := newGenerator(t, string(grammar), string(tokens))
+	g.pkg, g.memo = "generatedparser", true
+	src, err := g.generate(nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(expected), string(src), "generated parser is outdated. run go generate")
+	}
+}
+
+func TestGenerate_Skeleton(t *testing.T) {
+	old := `package parser
+
+import (
+	"fmt"
+
+	"github.com/shivamMg/rd"
+)
+
+// Sum = Number "+" Number .
+func Sum(b *rd.Builder) (ok bool) {
+	defer b.Enter("Sum").Sync(";").Exit(&ok)
+
+	// hand-edited
+	return Number(b) && b.Match("+") && Number(b)
+}
+
+func Number(b *rd.Builder) (ok bool) {
+	defer b.Enter("Number").Exit(&ok)
+
+	token, ok := b.Next()
+	fmt.Println(token)
+	return ok
+}
+`
+	g := newGenerator(t, `
+		Sum = Number ("+" | "-") Number .
+		Stmt = Sum ";" .
+	`, "")
+	g.pkg = ""
+	src, err := g.generate([]byte(old))
+	if !assert.NoError(t, err) {
+		return
+	}
+	expected := `package parser
+
+import (
+	"fmt"
+	"github.com/shivamMg/rd"
+)
+
+// Parse parses tokens starting with Sum.
+func Parse(tokens []rd.Token, opts ...rd.Option) (parseTree *rd.Tree, debugTree *rd.DebugTree, err error) {
+	b := rd.NewBuilder(tokens, opts...)
+	Sum(b)
+	return b.ParseTree(), b.DebugTree(), b.Err()
+}
+
+// Sum = Number ("+" | "-") Number .
+func Sum(b *rd.Builder) (ok bool) {
+	defer b.Enter("Sum").Sync(";").Exit(&ok)
+
+	// hand-edited
+	return Number(b) && b.Match("+") && Number(b)
+}
+
+// Stmt = Sum ";" .
+func Stmt(b *rd.Builder) (ok bool) {
+	defer b.Enter("Stmt").Exit(&ok)
+
+	return Sum(b) && b.Match(";")
+}
+
+func Number(b *rd.Builder) (ok bool) {
+	defer b.Enter("Number").Exit(&ok)
+
+	token, ok := b.Next()
+	fmt.Println(token)
+	return ok
+}
+`
+	assert.Equal(t, expected, string(src))
+
+	// regenerating doesn't change anything
+	again, err := g.generate(src)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(src), string(again))
+	}
+}
+
+func TestFuncName(t *testing.T) {
+	g := newGenerator(t, `a = "a" .`, "ldh-str LDHStr")
+	assert.Equal(t, "ExprPrime", g.funcName("Expr'"))
+	assert.Equal(t, "letDigHyp", g.funcName("let-dig-hyp"))
+	assert.Equal(t, "LDHStr", g.funcName("ldh-str"))
+	g.export = true
+	assert.Equal(t, "LetDigHyp", g.funcName("let-dig-hyp"))
+}
+
+func TestParseMapping_Errors(t *testing.T) {
+	tests := []struct {
+		mapping string
+		err     string
+	}{
+		{`import`, `line 1: expected a terminal or rule name followed by what it maps to`},
+		{`import "fmt`, `line 1: invalid import path "fmt`},
+		{"\n\"+", `line 2: unterminated or empty terminal`},
+		{`"+" Plus)`, `line 1: invalid expression for terminal "+": "Plus)"`},
+		{"'+' Plus\n\"+\" Add", `line 2: terminal "+" mapped again`},
+		{`ident 1dent`, `line 1: invalid function name for rule ident: "1dent"`},
+	}
+	for _, test := range tests {
+		_, err := parseMapping(strings.NewReader(test.mapping))
+		assert.EqualError(t, err, test.err, test.mapping)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/parser"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// mapping maps grammar terminals to Go expressions, and rule names to Go function
+// names. It's read from the file passed as -tokens.
+type mapping struct {
+	imports   []string          // import specs, ex. `. "example.com/tokens"`
+	terminals map[string]string // terminal -> Go expression
+	rules     map[string]string // rule name -> Go function name
+}
+
+// parseMapping parses a mapping. Each line is either empty, a comment starting
+// with # or //, an import, or a terminal (quoted, as in the grammar) or rule name
+// followed by what it maps to:
+//
+//	import . "github.com/shivamMg/rd/examples/pl0/tokens"
+//
+//	":="    Assignment
+//	"begin" Begin
+//	ident   Ident
+func parseMapping(r io.Reader) (*mapping, error) {
+	m := &mapping{terminals: map[string]string{}, rules: map[string]string{}}
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "//") {
+			continue
+		}
+		if err := m.parseLine(text); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *mapping) parseLine(text string) error {
+	if strings.HasPrefix(text, "import ") {
+		spec := strings.TrimSpace(strings.TrimPrefix(text, "import "))
+		fields := strings.Fields(spec)
+		if len(fields) == 0 || len(fields) > 2 {
+			return fmt.Errorf("invalid import %q", spec)
+		}
+		if _, err := strconv.Unquote(fields[len(fields)-1]); err != nil {
+			return fmt.Errorf("invalid import path %s", fields[len(fields)-1])
+		}
+		m.imports = append(m.imports, strings.Join(fields, " "))
+		return nil
+	}
+
+	var key, value string
+	if q := text[0]; q == '"' || q == '\'' {
+		end := strings.IndexByte(text[1:], q)
+		if end <= 0 {
+			return fmt.Errorf("unterminated or empty terminal")
+		}
+		key, value = text[1:end+1], strings.TrimSpace(text[end+2:])
+		if _, err := parser.ParseExpr(value); err != nil {
+			return fmt.Errorf("invalid expression for terminal %q: %q", key, value)
+		}
+		if _, ok := m.terminals[key]; ok {
+			return fmt.Errorf("terminal %q mapped again", key)
+		}
+		m.terminals[key] = value
+		return nil
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) != 2 {
+		return fmt.Errorf("expected a terminal or rule name followed by what it maps to")
+	}
+	key, value = fields[0], fields[1]
+	if !isIdent(value) {
+		return fmt.Errorf("invalid function name for rule %s: %q", key, value)
+	}
+	if _, ok := m.rules[key]; ok {
+		return fmt.Errorf("rule %s mapped again", key)
+	}
+	m.rules[key] = value
+	return nil
+}
@@ -0,0 +1,368 @@
+package rd
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"sync"
+	"text/tabwriter"
+)
+
+// Coverage is a Tracer that records which non-terminals were entered and which
+// succeeded, which of their alternatives succeeded, and which tokens (or Labels)
+// wanted by matches were ever matched. It aggregates across parses: add the same
+// Coverage to every Builder of a test suite using the Trace option, then report
+// what the suite never exercised. It's safe for concurrent use by Builders in
+// different goroutines.
+//
+// Alternatives are only known from calls to Builder's Alternative method (the
+// ebnf package's Interpreter makes them), which identify them by their
+// non-terminal and a label. Non-terminals, alternatives and tokens that are never
+// tried don't show up in events, so they must be declared using Declare,
+// DeclareAlternatives and DeclareMatches to appear in reports.
+type Coverage struct {
+	NopTracer
+	mu       sync.Mutex
+	nonTerms map[interface{}]*NonTermCoverage
+	alts     map[altKey]*AltCoverage
+	matches  map[Token]*MatchCoverage
+	// in the order they were declared or first seen
+	nonTermOrder []*NonTermCoverage
+	altOrder     []*AltCoverage
+	matchOrder   []*MatchCoverage
+}
+
+type altKey struct {
+	nonTerm interface{}
+	alt     string
+}
+
+// NonTermCoverage is how often a non-terminal was entered, and how often it
+// succeeded. It's covered if it succeeded at least once.
+type NonTermCoverage struct {
+	NonTerm            interface{}
+	Entered, Succeeded int
+}
+
+// AltCoverage is how often an alternative of a non-terminal was tried, and how
+// often it succeeded. It's covered if it succeeded at least once.
+type AltCoverage struct {
+	NonTerm          interface{}
+	Alt              string
+	Tried, Succeeded int
+}
+
+// MatchCoverage is how often a token (or a Label) was wanted by a match, and how
+// often it was matched. It's covered if it was matched at least once.
+type MatchCoverage struct {
+	Want               Token
+	Attempted, Matched int
+}
+
+// NewCoverage returns a new Coverage.
+func NewCoverage() *Coverage {
+	return &Coverage{
+		nonTerms: map[interface{}]*NonTermCoverage{},
+		alts:     map[altKey]*AltCoverage{},
+		matches:  map[Token]*MatchCoverage{},
+	}
+}
+
+// Declare adds non-terminals to the coverage, so that they're reported even if
+// they're never entered.
+func (c *Coverage) Declare(nonTerms ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, nt := range nonTerms {
+		c.nonTerm(nt)
+	}
+}
+
+// DeclareAlternatives adds alternatives of nonTerm, labelled as by Builder's
+// Alternative method, to the coverage, so that they're reported even if they're
+// never tried.
+func (c *Coverage) DeclareAlternatives(nonTerm interface{}, alts ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, alt := range alts {
+		c.alt(nonTerm, alt)
+	}
+}
+
+// DeclareMatches adds tokens (or Labels) to the coverage, so that they're
+// reported even if they're never wanted by a match.
+func (c *Coverage) DeclareMatches(wants ...Token) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, want := range wants {
+		c.match(want)
+	}
+}
+
+func (c *Coverage) nonTerm(nonTerm interface{}) *NonTermCoverage {
+	nc, ok := c.nonTerms[nonTerm]
+	if !ok {
+		nc = &NonTermCoverage{NonTerm: nonTerm}
+		c.nonTerms[nonTerm] = nc
+		c.nonTermOrder = append(c.nonTermOrder, nc)
+	}
+	return nc
+}
+
+func (c *Coverage) alt(nonTerm interface{}, alt string) *AltCoverage {
+	key := altKey{nonTerm, alt}
+	ac, ok := c.alts[key]
+	if !ok {
+		ac = &AltCoverage{NonTerm: nonTerm, Alt: alt}
+		c.alts[key] = ac
+		c.altOrder = append(c.altOrder, ac)
+	}
+	return ac
+}
+
+func (c *Coverage) match(want Token) *MatchCoverage {
+	mc, ok := c.matches[want]
+	if !ok {
+		mc = &MatchCoverage{Want: want}
+		c.matches[want] = mc
+		c.matchOrder = append(c.matchOrder, mc)
+	}
+	return mc
+}
+
+func (c *Coverage) Enter(e EnterEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nonTerm(e.NonTerm).Entered++
+}
+
+func (c *Coverage) Exit(e ExitEvent) {
+	if !e.Result {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nonTerm(e.NonTerm).Succeeded++
+}
+
+func (c *Coverage) Alternative(e AlternativeEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ac := c.alt(e.NonTerm, e.Alt)
+	ac.Tried++
+	if e.OK {
+		ac.Succeeded++
+	}
+}
+
+func (c *Coverage) Match(e MatchEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	mc := c.match(e.Want)
+	mc.Attempted++
+	if e.OK {
+		mc.Matched++
+	}
+}
+
+// NonTerms returns the coverage of non-terminals, in the order they were
+// declared or first entered.
+func (c *Coverage) NonTerms() []NonTermCoverage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ncs := make([]NonTermCoverage, len(c.nonTermOrder))
+	for i, nc := range c.nonTermOrder {
+		ncs[i] = *nc
+	}
+	return ncs
+}
+
+// Alternatives returns the coverage of alternatives, in the order they were
+// declared or first tried.
+func (c *Coverage) Alternatives() []AltCoverage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	acs := make([]AltCoverage, len(c.altOrder))
+	for i, ac := range c.altOrder {
+		acs[i] = *ac
+	}
+	return acs
+}
+
+// Matches returns the coverage of tokens wanted by matches, in the order they were
+// declared or first wanted.
+func (c *Coverage) Matches() []MatchCoverage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	mcs := make([]MatchCoverage, len(c.matchOrder))
+	for i, mc := range c.matchOrder {
+		mcs[i] = *mc
+	}
+	return mcs
+}
+
+// Percent returns the percentage of covered non-terminals, alternatives and
+// matches. Each is 100 if there are none.
+func (c *Coverage) Percent() (nonTerms, alts, matches float64) {
+	var covered int
+	ncs := c.NonTerms()
+	for _, nc := range ncs {
+		if nc.Succeeded > 0 {
+			covered++
+		}
+	}
+	nonTerms = percent(covered, len(ncs))
+	covered = 0
+	acs := c.Alternatives()
+	for _, ac := range acs {
+		if ac.Succeeded > 0 {
+			covered++
+		}
+	}
+	alts = percent(covered, len(acs))
+	covered = 0
+	mcs := c.Matches()
+	for _, mc := range mcs {
+		if mc.Matched > 0 {
+			covered++
+		}
+	}
+	return nonTerms, alts, percent(covered, len(mcs))
+}
+
+func percent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(covered) / float64(total)
+}
+
+// nonTermStatus returns why nc isn't covered. Returns "" if it is.
+func nonTermStatus(nc NonTermCoverage) string {
+	switch {
+	case nc.Entered == 0:
+		return "never entered"
+	case nc.Succeeded == 0:
+		return "never succeeded"
+	}
+	return ""
+}
+
+// altStatus returns why ac isn't covered. Returns "" if it is.
+func altStatus(ac AltCoverage) string {
+	switch {
+	case ac.Tried == 0:
+		return "never tried"
+	case ac.Succeeded == 0:
+		return "never succeeded"
+	}
+	return ""
+}
+
+// matchStatus returns why mc isn't covered. Returns "" if it is.
+func matchStatus(mc MatchCoverage) string {
+	switch {
+	case mc.Attempted == 0:
+		return "never attempted"
+	case mc.Matched == 0:
+		return "never matched"
+	}
+	return ""
+}
+
+// WriteText writes a coverage report: the percentage of covered non-terminals,
+// alternatives (if any are known) and matches, followed by counts for each of
+// them. Ones that aren't covered are marked with why.
+func (c *Coverage) WriteText(w io.Writer) error {
+	nonTerms, alts, matches := c.Percent()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "non-terminals: %.1f%% covered\n", nonTerms)
+	fmt.Fprintln(tw, "entered\tsucceeded\t non-terminal")
+	for _, nc := range c.NonTerms() {
+		fmt.Fprintf(tw, "%d\t%d\t %v%s\n", nc.Entered, nc.Succeeded, nc.NonTerm, parenthesize(nonTermStatus(nc)))
+	}
+	if acs := c.Alternatives(); len(acs) > 0 {
+		fmt.Fprintf(tw, "alternatives: %.1f%% covered\n", alts)
+		fmt.Fprintln(tw, "tried\tsucceeded\t alternative")
+		for _, ac := range acs {
+			fmt.Fprintf(tw, "%d\t%d\t %v: %s%s\n", ac.Tried, ac.Succeeded, ac.NonTerm, ac.Alt, parenthesize(altStatus(ac)))
+		}
+	}
+	fmt.Fprintf(tw, "matches: %.1f%% covered\n", matches)
+	fmt.Fprintln(tw, "attempted\tmatched\t want")
+	for _, mc := range c.Matches() {
+		fmt.Fprintf(tw, "%d\t%d\t %v%s\n", mc.Attempted, mc.Matched, mc.Want, parenthesize(matchStatus(mc)))
+	}
+	return tw.Flush()
+}
+
+func parenthesize(status string) string {
+	if status == "" {
+		return ""
+	}
+	return " (" + status + ")"
+}
+
+const coverageStyle = `
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { padding: 2px 12px; text-align: right; }
+th:first-child, td:first-child, td:last-child { text-align: left; }
+tr.covered { background: #dfd; }
+tr.partial { background: #ffd; }
+tr.uncovered { background: #fdd; }
+`
+
+// WriteHTML writes the coverage report as an HTML page titled title. Rows of
+// covered non-terminals, alternatives and matches are green. Ones that were tried
+// but never succeeded are yellow, and ones that were never tried are red.
+func (c *Coverage) WriteHTML(w io.Writer, title string) error {
+	nonTerms, alts, matches := c.Percent()
+	var buf bytes.Buffer
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&buf, "<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n", html.EscapeString(title), coverageStyle)
+	fmt.Fprintf(&buf, "<h1>%s</h1>\n", html.EscapeString(title))
+	row := func(class string, cells ...interface{}) {
+		fmt.Fprintf(&buf, "<tr class=\"%s\">", class)
+		for _, cell := range cells {
+			fmt.Fprintf(&buf, "<td>%s</td>", html.EscapeString(fmt.Sprint(cell)))
+		}
+		buf.WriteString("</tr>\n")
+	}
+
+	fmt.Fprintf(&buf, "<h2>Non-terminals: %.1f%% covered</h2>\n<table>\n", nonTerms)
+	buf.WriteString("<tr><th>non-terminal</th><th>entered</th><th>succeeded</th><th></th></tr>\n")
+	for _, nc := range c.NonTerms() {
+		row(coverageClass(nc.Entered, nc.Succeeded), nc.NonTerm, nc.Entered, nc.Succeeded, nonTermStatus(nc))
+	}
+	buf.WriteString("</table>\n")
+
+	if acs := c.Alternatives(); len(acs) > 0 {
+		fmt.Fprintf(&buf, "<h2>Alternatives: %.1f%% covered</h2>\n<table>\n", alts)
+		buf.WriteString("<tr><th>non-terminal</th><th>alternative</th><th>tried</th><th>succeeded</th><th></th></tr>\n")
+		for _, ac := range acs {
+			row(coverageClass(ac.Tried, ac.Succeeded), ac.NonTerm, ac.Alt, ac.Tried, ac.Succeeded, altStatus(ac))
+		}
+		buf.WriteString("</table>\n")
+	}
+
+	fmt.Fprintf(&buf, "<h2>Matches: %.1f%% covered</h2>\n<table>\n", matches)
+	buf.WriteString("<tr><th>want</th><th>attempted</th><th>matched</th><th></th></tr>\n")
+	for _, mc := range c.Matches() {
+		row(coverageClass(mc.Attempted, mc.Matched), mc.Want, mc.Attempted, mc.Matched, matchStatus(mc))
+	}
+	buf.WriteString("</table>\n</body>\n</html>\n")
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func coverageClass(tried, succeeded int) string {
+	switch {
+	case succeeded > 0:
+		return "covered"
+	case tried > 0:
+		return "partial"
+	}
+	return "uncovered"
+}
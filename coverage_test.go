@@ -0,0 +1,118 @@
+package rd
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverage(t *testing.T) {
+	c := NewCoverage()
+	c.Declare("Expr", "Term", "Factor")
+	c.DeclareMatches("x", "+", "-")
+	var wg sync.WaitGroup
+	for _, tokens := range [][]Token{{"x", "+", "x"}, {"x", "+", "("}} {
+		wg.Add(1)
+		go func(tokens []Token) {
+			defer wg.Done()
+			benchExpr(NewBuilder(tokens, Trace(c)))
+		}(tokens)
+	}
+	wg.Wait()
+
+	assert.Equal(t, []NonTermCoverage{
+		{NonTerm: "Expr", Entered: 3, Succeeded: 1},
+		{NonTerm: "Term", Entered: 5, Succeeded: 3},
+		{NonTerm: "Factor"},
+	}, c.NonTerms())
+	assert.Equal(t, []MatchCoverage{
+		{Want: "x", Attempted: 4, Matched: 3},
+		{Want: "+", Attempted: 3, Matched: 2},
+		{Want: "-"},
+		{Want: "(", Attempted: 5, Matched: 1},
+	}, c.Matches())
+	nonTerms, alts, matches := c.Percent()
+	assert.InDelta(t, 66.7, nonTerms, 0.1)
+	assert.InDelta(t, 100, alts, 0.1)
+	assert.InDelta(t, 75, matches, 0.1)
+
+	var buf bytes.Buffer
+	assert.Nil(t, c.WriteText(&buf))
+	expected := `non-terminals: 66.7% covered
+  entered  succeeded non-terminal
+        3          1 Expr
+        5          3 Term
+        0          0 Factor (never entered)
+matches: 75.0% covered
+  attempted  matched want
+          4        3 x
+          3        2 +
+          0        0 - (never attempted)
+          5        1 (
+`
+	assert.Equal(t, expected, buf.String())
+
+	buf.Reset()
+	assert.Nil(t, c.WriteHTML(&buf, "<coverage>"))
+	assert.Contains(t, buf.String(), "<title>&lt;coverage&gt;</title>")
+	assert.Equal(t, 5, strings.Count(buf.String(), `<tr class="covered">`))
+	assert.Contains(t, buf.String(), `<tr class="uncovered"><td>Factor</td><td>0</td><td>0</td><td>never entered</td></tr>`)
+}
+
+func TestCoverage_Alternatives(t *testing.T) {
+	// Factor = "(" "x" ")" | "(" "y" ")" | "x" .
+	factor := func(b *Builder) (ok bool) {
+		defer b.Enter("Factor").Exit(&ok)
+
+		if b.Alternative(`"(" "x" ")"`, b.Match("(") && b.Match("x") && b.Match(")")) {
+			return true
+		}
+		b.Backtrack()
+		if b.Alternative(`"(" "y" ")"`, b.Match("(") && b.Match("y") && b.Match(")")) {
+			return true
+		}
+		b.Backtrack()
+		return b.Alternative(`"x"`, b.Match("x"))
+	}
+	c := NewCoverage()
+	c.DeclareAlternatives("Factor", `"(" "x" ")"`, `"(" "y" ")"`, `"x"`, `"z"`)
+	for _, tokens := range [][]Token{{"(", "x", ")"}, {"x"}} {
+		factor(NewBuilder(tokens, Trace(c)))
+	}
+
+	assert.Equal(t, []AltCoverage{
+		{NonTerm: "Factor", Alt: `"(" "x" ")"`, Tried: 2, Succeeded: 1},
+		{NonTerm: "Factor", Alt: `"(" "y" ")"`, Tried: 1},
+		{NonTerm: "Factor", Alt: `"x"`, Tried: 1, Succeeded: 1},
+		{NonTerm: "Factor", Alt: `"z"`},
+	}, c.Alternatives())
+	_, alts, _ := c.Percent()
+	assert.InDelta(t, 50, alts, 0.1)
+
+	var buf bytes.Buffer
+	assert.Nil(t, c.WriteText(&buf))
+	expected := `non-terminals: 100.0% covered
+  entered  succeeded non-terminal
+        2          2 Factor
+alternatives: 50.0% covered
+  tried  succeeded alternative
+      2          1 Factor: "(" "x" ")"
+      1          0 Factor: "(" "y" ")" (never succeeded)
+      1          1 Factor: "x"
+      0          0 Factor: "z" (never tried)
+matches: 100.0% covered
+  attempted  matched want
+          3        1 (
+          2        2 x
+          1        1 )
+`
+	assert.Equal(t, expected, buf.String())
+
+	buf.Reset()
+	assert.Nil(t, c.WriteHTML(&buf, "coverage"))
+	assert.Contains(t, buf.String(), "<h2>Alternatives: 50.0% covered</h2>")
+	assert.Contains(t, buf.String(), `<tr class="partial"><td>Factor</td><td>&#34;(&#34; &#34;y&#34; &#34;)&#34;</td><td>1</td><td>0</td><td>never succeeded</td></tr>`)
+}
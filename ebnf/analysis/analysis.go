@@ -0,0 +1,233 @@
+// Package analysis analyzes EBNF grammars parsed by package ebnf. It computes
+// nullable rules and FIRST and FOLLOW sets, and reports issues that would make a
+// parser for the grammar misbehave: LL(1) conflicts, unreachable alternatives,
+// left recursion, and unreachable or unproductive rules.
+//
+// Rules that aren't defined in the grammar (ex. a rule matching identifiers,
+// written by hand) are treated like terminals: they appear in FIRST and FOLLOW
+// sets under their names, while terminals appear quoted.
+//
+// Analysis can be run from a test, to catch issues as the grammar changes:
+//
+//	func TestGrammar(t *testing.T) {
+//		if err := analysis.Analyze(ebnf.MustParse(Grammar), "").Err(); err != nil {
+//			t.Error(err)
+//		}
+//	}
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/shivamMg/rd"
+	"github.com/shivamMg/rd/ebnf"
+)
+
+// End is the end of input in FOLLOW sets.
+const End = "$"
+
+// Set is a set of terminals and undefined rules.
+type Set map[string]bool
+
+// add adds all of o to s, and reports whether s changed.
+func (s Set) add(o Set) (changed bool) {
+	for t := range o {
+		if !s[t] {
+			s[t], changed = true, true
+		}
+	}
+	return changed
+}
+
+// Sorted returns s's elements in sorted order.
+func (s Set) Sorted() []string {
+	elems := make([]string, 0, len(s))
+	for t := range s {
+		elems = append(elems, t)
+	}
+	sort.Strings(elems)
+	return elems
+}
+
+func (s Set) String() string {
+	return "{" + strings.Join(s.Sorted(), ", ") + "}"
+}
+
+func intersect(a, b Set) Set {
+	s := Set{}
+	for t := range a {
+		if b[t] {
+			s[t] = true
+		}
+	}
+	return s
+}
+
+// Analysis is the result of analyzing a grammar.
+type Analysis struct {
+	Grammar *ebnf.Grammar
+	// Start is the rule parsing starts with.
+	Start string
+	// Nullable contains rules that can match without consuming tokens.
+	Nullable map[string]bool
+	// First contains, for each rule, the tokens it can start with.
+	First map[string]Set
+	// Follow contains, for each rule, the tokens that can come right after it.
+	// It contains End if the rule can end the input.
+	Follow map[string]Set
+	// Issues are in the order of the rules they're found in.
+	Issues []*Issue
+}
+
+// Analyze analyzes g. If start is empty, the first rule is used as the start
+// rule.
+func Analyze(g *ebnf.Grammar, start string) *Analysis {
+	if start == "" && len(g.Rules) > 0 {
+		start = g.Rules[0].Name
+	}
+	a := &Analysis{
+		Grammar:  g,
+		Start:    start,
+		Nullable: map[string]bool{},
+		First:    map[string]Set{},
+		Follow:   map[string]Set{},
+	}
+	for _, r := range g.Rules {
+		a.First[r.Name], a.Follow[r.Name] = Set{}, Set{}
+	}
+	if a.Follow[start] != nil {
+		a.Follow[start][End] = true
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, r := range g.Rules {
+			if !a.Nullable[r.Name] && a.IsNullable(r.Expr) {
+				a.Nullable[r.Name], changed = true, true
+			}
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range g.Rules {
+			changed = a.First[r.Name].add(a.FirstOf(r.Expr)) || changed
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range g.Rules {
+			walkFollow(a, r.Expr, a.Follow[r.Name], func(e ebnf.Expr, follow Set) {
+				if n, ok := e.(ebnf.NonTerminal); ok && a.defined(n) {
+					changed = a.Follow[string(n)].add(follow) || changed
+				}
+			})
+		}
+	}
+
+	a.checkRules()
+	return a
+}
+
+func (a *Analysis) defined(n ebnf.NonTerminal) bool {
+	return a.Grammar.Rule(string(n)) != nil
+}
+
+// IsNullable reports whether e can match without consuming tokens.
+func (a *Analysis) IsNullable(e ebnf.Expr) bool {
+	switch e := e.(type) {
+	case ebnf.Terminal:
+		return false
+	case ebnf.NonTerminal:
+		return a.Nullable[string(e)]
+	case ebnf.Group:
+		return a.IsNullable(e.Expr)
+	case ebnf.Alternation:
+		for _, alt := range e {
+			if a.IsNullable(alt) {
+				return true
+			}
+		}
+		return false
+	case ebnf.Sequence:
+		for _, item := range e {
+			if !a.IsNullable(item) {
+				return false
+			}
+		}
+		return true
+	}
+	// Option, Repetition, Empty
+	return true
+}
+
+// FirstOf returns the tokens e can start with.
+func (a *Analysis) FirstOf(e ebnf.Expr) Set {
+	s := Set{}
+	switch e := e.(type) {
+	case ebnf.Terminal:
+		s[e.String()] = true
+	case ebnf.NonTerminal:
+		if !a.defined(e) {
+			s[string(e)] = true
+		} else {
+			s.add(a.First[string(e)])
+		}
+	case ebnf.Group:
+		return a.FirstOf(e.Expr)
+	case ebnf.Option:
+		return a.FirstOf(e.Expr)
+	case ebnf.Repetition:
+		return a.FirstOf(e.Expr)
+	case ebnf.Alternation:
+		for _, alt := range e {
+			s.add(a.FirstOf(alt))
+		}
+	case ebnf.Sequence:
+		for _, item := range e {
+			s.add(a.FirstOf(item))
+			if !a.IsNullable(item) {
+				break
+			}
+		}
+	}
+	return s
+}
+
+// walkFollow calls f for e and all expressions nested inside it, along with the
+// tokens that can follow them. follow is what can follow e.
+func walkFollow(a *Analysis, e ebnf.Expr, follow Set, f func(e ebnf.Expr, follow Set)) {
+	f(e, follow)
+	switch e := e.(type) {
+	case ebnf.Alternation:
+		for _, alt := range e {
+			walkFollow(a, alt, follow, f)
+		}
+	case ebnf.Sequence:
+		for i := len(e) - 1; i >= 0; i-- {
+			walkFollow(a, e[i], follow, f)
+			next := a.FirstOf(e[i])
+			if a.IsNullable(e[i]) {
+				next.add(follow)
+			}
+			follow = next
+		}
+	case ebnf.Group:
+		walkFollow(a, e.Expr, follow, f)
+	case ebnf.Option:
+		walkFollow(a, e.Expr, follow, f)
+	case ebnf.Repetition:
+		next := a.FirstOf(e.Expr)
+		next.add(follow)
+		walkFollow(a, e.Expr, next, f)
+	}
+}
+
+// Err returns the issues found as an rd.ErrorList. Returns nil if there are none.
+func (a *Analysis) Err() error {
+	var errs rd.ErrorList
+	for _, issue := range a.Issues {
+		errs.Add(issue)
+	}
+	return errs.Err()
+}
@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/shivamMg/rd/ebnf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyze(t *testing.T) {
+	a := Analyze(ebnf.MustParse(`
+		Expr  = Term {("+" | "-") Term} .
+		Term  = ["-"] Factor .
+		Factor = "(" Expr ")" | Number .
+	`), "")
+	assert.Empty(t, a.Issues)
+	assert.NoError(t, a.Err())
+	assert.Equal(t, map[string]bool{}, a.Nullable)
+	assert.Equal(t, `{"(", "-", Number}`, a.First["Expr"].String())
+	assert.Equal(t, `{"(", Number}`, a.First["Factor"].String())
+	assert.Equal(t, `{")", $}`, a.Follow["Expr"].String())
+	assert.Equal(t, `{")", "+", "-", $}`, a.Follow["Factor"].String())
+
+	a = Analyze(ebnf.MustParse(`
+		List = {Item} .
+		Item = ["-"] | "x" .
+	`), "")
+	// Item can be followed by another Item
+	if assert.Len(t, a.Issues, 3) {
+		assert.Equal(t, UnreachableAlternative, a.Issues[0].Kind)
+		assert.Equal(t, FirstFollow, a.Issues[1].Kind)
+		assert.Equal(t, []string{`"x"`}, a.Issues[1].Tokens)
+		assert.Equal(t, FirstFollow, a.Issues[2].Kind)
+		assert.Equal(t, ebnf.Option{Expr: ebnf.Terminal("-")}, a.Issues[2].Expr)
+	}
+	assert.Equal(t, map[string]bool{"List": true, "Item": true}, a.Nullable)
+	assert.Equal(t, `{$}`, a.Follow["List"].String())
+	assert.Equal(t, `{"-", "x", $}`, a.Follow["Item"].String())
+}
+
+func TestAnalyze_Issues(t *testing.T) {
+	a := Analyze(ebnf.MustParse(`A = B "x" | [C] "y" | E .
+B = C | "z" .
+C = ["c"] C "y" | "c" .
+D = "d" .
+E = F .
+F = E "f" .
+`), "")
+	expected := []string{
+		`1:1: A: FIRST/FIRST conflict: alternatives B "x" and [C] "y" can both start with "c"`,
+		`3:1: C: left recursion: C → C`,
+		`3:1: C: FIRST/FIRST conflict: alternatives ["c"] C "y" and "c" can both start with "c"`,
+		`3:1: C: FIRST/FOLLOW conflict: can't decide whether ["c"] matches or is skipped on "c"`,
+		`4:1: D: unreachable rule: not used by A`,
+		`5:1: E: unproductive rule: can't match any input`,
+		`5:1: E: left recursion: E → F → E`,
+		`6:1: F: unproductive rule: can't match any input`,
+	}
+	var got []string
+	for _, issue := range a.Issues {
+		got = append(got, issue.Error())
+	}
+	assert.Equal(t, expected, got)
+	assert.EqualError(t, a.Err(), expected[0]+" (and 7 more errors)")
+}
@@ -0,0 +1,250 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shivamMg/rd/ebnf"
+)
+
+// Kind is the kind of an Issue.
+type Kind int
+
+const (
+	// FirstFirst is an LL(1) conflict between alternatives that can start with
+	// the same token.
+	FirstFirst Kind = iota
+	// FirstFollow is an LL(1) conflict for an expression that can be empty, and
+	// can start with a token that can also follow it.
+	FirstFollow
+	// UnreachableAlternative is an alternative that's never tried, since an
+	// earlier alternative always matches, as it can be empty.
+	UnreachableAlternative
+	// LeftRecursion is a rule that can refer to itself before consuming tokens.
+	LeftRecursion
+	// UnreachableRule is a rule that isn't referred to, directly or indirectly,
+	// by the start rule.
+	UnreachableRule
+	// UnproductiveRule is a rule that can't match any input, since it always
+	// ends up referring to itself.
+	UnproductiveRule
+)
+
+func (k Kind) String() string {
+	switch k {
+	case FirstFirst:
+		return "FIRST/FIRST conflict"
+	case FirstFollow:
+		return "FIRST/FOLLOW conflict"
+	case UnreachableAlternative:
+		return "unreachable alternative"
+	case LeftRecursion:
+		return "left recursion"
+	case UnreachableRule:
+		return "unreachable rule"
+	case UnproductiveRule:
+		return "unproductive rule"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Issue is an issue found in a grammar rule.
+type Issue struct {
+	Kind Kind
+	Rule *ebnf.Rule
+	// Expr is the expression involved: the alternation for FirstFirst and
+	// UnreachableAlternative, and the expression that can be empty for
+	// FirstFollow. It's nil for other kinds.
+	Expr ebnf.Expr
+	// Tokens are the tokens conflicts are on.
+	Tokens []string
+	// Cycle is the rules involved in left recursion, starting and ending with
+	// Rule.
+	Cycle []string
+	Msg   string
+}
+
+// Error returns the issue prefixed with the rule's position in the grammar, ex.
+// "2:2: Expr: left recursion: Expr → Expr".
+func (i *Issue) Error() string {
+	return fmt.Sprintf("%v: %s: %s: %s", i.Rule.Span.StartPos, i.Rule.Name, i.Kind, i.Msg)
+}
+
+func (a *Analysis) report(issue *Issue) {
+	a.Issues = append(a.Issues, issue)
+}
+
+func (a *Analysis) checkRules() {
+	reachable := a.reachable()
+	productive := a.productive()
+	for _, r := range a.Grammar.Rules {
+		if !reachable[r.Name] {
+			a.report(&Issue{Kind: UnreachableRule, Rule: r, Msg: "not used by " + a.Start})
+		}
+		if !productive[r.Name] {
+			a.report(&Issue{Kind: UnproductiveRule, Rule: r, Msg: "can't match any input"})
+		}
+		if cycle := a.leftRecursion(r); cycle != nil {
+			a.report(&Issue{Kind: LeftRecursion, Rule: r, Cycle: cycle, Msg: strings.Join(cycle, " → ")})
+		}
+		walkFollow(a, r.Expr, a.Follow[r.Name], func(e ebnf.Expr, follow Set) {
+			a.checkExpr(r, e, follow)
+		})
+	}
+}
+
+func (a *Analysis) checkExpr(r *ebnf.Rule, e ebnf.Expr, follow Set) {
+	switch e := e.(type) {
+	case ebnf.Alternation:
+		for i, alt := range e {
+			for _, other := range e[i+1:] {
+				if common := intersect(a.FirstOf(alt), a.FirstOf(other)); len(common) > 0 {
+					a.report(&Issue{Kind: FirstFirst, Rule: r, Expr: e, Tokens: common.Sorted(),
+						Msg: fmt.Sprintf("alternatives %s and %s can both start with %s", alt, other, strings.Join(common.Sorted(), ", "))})
+				}
+			}
+		}
+		for i, alt := range e[:len(e)-1] {
+			if a.IsNullable(alt) {
+				a.report(&Issue{Kind: UnreachableAlternative, Rule: r, Expr: e,
+					Msg: fmt.Sprintf("alternatives after %s are never tried, as it can be empty", alt)})
+				others := append(append(ebnf.Alternation{}, e[:i]...), e[i+1:]...)
+				a.checkFirstFollow(r, e, a.FirstOf(others), follow)
+				break
+			}
+		}
+	case ebnf.Option:
+		a.checkFirstFollow(r, e, a.FirstOf(e.Expr), follow)
+	case ebnf.Repetition:
+		a.checkFirstFollow(r, e, a.FirstOf(e.Expr), follow)
+	}
+}
+
+func (a *Analysis) checkFirstFollow(r *ebnf.Rule, e ebnf.Expr, first, follow Set) {
+	if common := intersect(first, follow); len(common) > 0 {
+		a.report(&Issue{Kind: FirstFollow, Rule: r, Expr: e, Tokens: common.Sorted(),
+			Msg: fmt.Sprintf("can't decide whether %s matches or is skipped on %s", e, strings.Join(common.Sorted(), ", "))})
+	}
+}
+
+// reachable returns rules referred to by the start rule, directly or indirectly.
+func (a *Analysis) reachable() map[string]bool {
+	reachable := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		r := a.Grammar.Rule(name)
+		if r == nil || reachable[name] {
+			return
+		}
+		reachable[name] = true
+		ebnf.Walk(r.Expr, func(e ebnf.Expr) {
+			if n, ok := e.(ebnf.NonTerminal); ok {
+				visit(string(n))
+			}
+		})
+	}
+	visit(a.Start)
+	return reachable
+}
+
+// productive returns rules that can match some input. Undefined rules are
+// assumed to be productive.
+func (a *Analysis) productive() map[string]bool {
+	productive := map[string]bool{}
+	var isProductive func(e ebnf.Expr) bool
+	isProductive = func(e ebnf.Expr) bool {
+		switch e := e.(type) {
+		case ebnf.NonTerminal:
+			return !a.defined(e) || productive[string(e)]
+		case ebnf.Group:
+			return isProductive(e.Expr)
+		case ebnf.Alternation:
+			for _, alt := range e {
+				if isProductive(alt) {
+					return true
+				}
+			}
+			return false
+		case ebnf.Sequence:
+			for _, item := range e {
+				if !isProductive(item) {
+					return false
+				}
+			}
+		}
+		// Terminal, Option, Repetition, Empty
+		return true
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range a.Grammar.Rules {
+			if !productive[r.Name] && isProductive(r.Expr) {
+				productive[r.Name], changed = true, true
+			}
+		}
+	}
+	return productive
+}
+
+// leftRecursion returns the shortest cycle of rules through which r refers to
+// itself before consuming tokens, starting and ending with r. Returns nil if
+// there's none, or if the cycle is reported for an earlier rule in it.
+func (a *Analysis) leftRecursion(r *ebnf.Rule) []string {
+	order := map[string]int{}
+	for i, r := range a.Grammar.Rules {
+		order[r.Name] = i
+	}
+	// breadth-first search over rules referred to at the left
+	prev := map[string]string{}
+	queue := []string{r.Name}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, left := range a.leftRules(a.Grammar.Rule(name).Expr) {
+			if left == r.Name {
+				cycle := []string{r.Name}
+				for n := name; n != r.Name; n = prev[n] {
+					if order[n] < order[r.Name] {
+						return nil
+					}
+					cycle = append([]string{n}, cycle...)
+				}
+				return append([]string{r.Name}, cycle...)
+			}
+			if _, ok := prev[left]; !ok {
+				prev[left] = name
+				queue = append(queue, left)
+			}
+		}
+	}
+	return nil
+}
+
+// leftRules returns the defined rules e can refer to before consuming tokens.
+func (a *Analysis) leftRules(e ebnf.Expr) []string {
+	var rules []string
+	switch e := e.(type) {
+	case ebnf.NonTerminal:
+		if a.defined(e) {
+			rules = append(rules, string(e))
+		}
+	case ebnf.Group:
+		return a.leftRules(e.Expr)
+	case ebnf.Option:
+		return a.leftRules(e.Expr)
+	case ebnf.Repetition:
+		return a.leftRules(e.Expr)
+	case ebnf.Alternation:
+		for _, alt := range e {
+			rules = append(rules, a.leftRules(alt)...)
+		}
+	case ebnf.Sequence:
+		for _, item := range e {
+			rules = append(rules, a.leftRules(item)...)
+			if !a.IsNullable(item) {
+				break
+			}
+		}
+	}
+	return rules
+}
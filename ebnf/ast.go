@@ -0,0 +1,175 @@
+// Package ebnf parses grammars written in Wirth-style EBNF, and interprets them on
+// top of rd.Builder.
+//
+// A grammar is a list of rules. Each rule names an expression, and optionally
+// ends with a period. Expressions are made of quoted terminals ("a" or 'a'),
+// names of other rules, ε for an empty production, and the following operators
+// (in decreasing order of precedence):
+//
+//	( e )    grouping
+//	[ e ]    option: zero or one e
+//	{ e }    repetition: zero or more e
+//	e1 e2    sequence
+//	e1 | e2  alternation
+//
+// ex.
+//
+//	Expr   = Term { ("+" | "-") Term } .
+//	Term   = Factor { ("*" | "/") Factor } .
+//	Factor = "(" Expr ")" | "-" Factor | Number .
+package ebnf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shivamMg/rd"
+)
+
+// Grammar is a parsed EBNF grammar.
+type Grammar struct {
+	// Rules are in the order they appear in the grammar.
+	Rules []*Rule
+}
+
+// Rule returns the rule named name. Returns nil if there's none.
+func (g *Grammar) Rule(name string) *Rule {
+	for _, r := range g.Rules {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+func (g *Grammar) String() string {
+	var b strings.Builder
+	for _, r := range g.Rules {
+		b.WriteString(r.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Rule is a grammar rule: Name = Expr .
+type Rule struct {
+	Name string
+	Expr Expr
+	// Span is the rule's span in the grammar's tokens. Its source positions
+	// point inside the grammar text.
+	Span rd.Span
+}
+
+func (r *Rule) String() string {
+	return fmt.Sprintf("%s = %s .", r.Name, r.Expr)
+}
+
+// Expr is an EBNF expression. It's one of Alternation, Sequence, Group, Option,
+// Repetition, Terminal, NonTerminal or Empty.
+type Expr interface {
+	fmt.Stringer
+	expr()
+}
+
+// Alternation is a list of alternatives: e1 | e2 | ...
+type Alternation []Expr
+
+// Sequence is a list of expressions: e1 e2 ...
+type Sequence []Expr
+
+// Group is a parenthesized expression: ( e )
+type Group struct {
+	Expr Expr
+}
+
+// Option is an optional expression: [ e ]
+type Option struct {
+	Expr Expr
+}
+
+// Repetition is an expression repeated zero or more times: { e }
+type Repetition struct {
+	Expr Expr
+}
+
+// Terminal is a quoted terminal, without quotes.
+type Terminal string
+
+// NonTerminal is a reference to a rule by its name.
+type NonTerminal string
+
+// Empty is the empty production: ε
+type Empty struct{}
+
+func (Alternation) expr() {}
+func (Sequence) expr()    {}
+func (Group) expr()       {}
+func (Option) expr()      {}
+func (Repetition) expr()  {}
+func (Terminal) expr()    {}
+func (NonTerminal) expr() {}
+func (Empty) expr()       {}
+
+func (a Alternation) String() string {
+	return joinExprs(a, " | ")
+}
+
+func (s Sequence) String() string {
+	return joinExprs(s, " ")
+}
+
+func (g Group) String() string {
+	return "(" + g.Expr.String() + ")"
+}
+
+func (o Option) String() string {
+	return "[" + o.Expr.String() + "]"
+}
+
+func (r Repetition) String() string {
+	return "{" + r.Expr.String() + "}"
+}
+
+func (t Terminal) String() string {
+	if strings.Contains(string(t), `"`) {
+		return "'" + string(t) + "'"
+	}
+	return `"` + string(t) + `"`
+}
+
+func (n NonTerminal) String() string {
+	return string(n)
+}
+
+func (Empty) String() string {
+	return "ε"
+}
+
+func joinExprs(exprs []Expr, sep string) string {
+	s := make([]string, len(exprs))
+	for i, e := range exprs {
+		s[i] = e.String()
+	}
+	return strings.Join(s, sep)
+}
+
+// Walk calls f for e and all expressions nested inside it, depth-first.
+func Walk(e Expr, f func(Expr)) {
+	f(e)
+	switch e := e.(type) {
+	case Alternation:
+		for _, alt := range e {
+			Walk(alt, f)
+		}
+	case Sequence:
+		for _, item := range e {
+			Walk(item, f)
+		}
+	case Group:
+		Walk(e.Expr, f)
+	case Option:
+		Walk(e.Expr, f)
+	case Repetition:
+		Walk(e.Expr, f)
+	}
+}
@@ -0,0 +1,270 @@
+package ebnf
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/shivamMg/rd"
+	"github.com/stretchr/testify/assert"
+)
+
+// copied from examples/pl0/parser
+const pl0Grammar = `
+	program = block "." .
+
+	block =
+		["const" ident "=" number {"," ident "=" number} ";"]
+		["var" ident {"," ident} ";"]
+		{"procedure" ident ";" block ";"} statement .
+
+	statement =
+		ident ":=" expression
+		| "!" expression
+		| "?" ident
+		| "call" ident
+		| "begin" statement {";" statement } "end"
+		| "if" condition "then" statement
+		| "while" condition "do" statement .
+
+	condition =
+		"odd" expression
+		| expression ("="|"#"|"<"|"<="|">"|">=") expression .
+
+	expression = ["+"|"-"] term {("+"|"-") term} .
+
+	term = factor {("*"|"/") factor} .
+
+	factor =
+		ident
+		| number
+		| "(" expression ")" .
+`
+
+// copied from examples/arithmetic/backtrackingparser
+const arithmeticGrammar = `
+	Expr   = Term "+" Expr | Term "-" Expr | Term
+	Term   = Factor "*" Term | Factor "/" Term | Factor
+	Factor = "(" Expr ")" | "-" Factor | Number
+`
+
+func number(b *rd.Builder) (ok bool) {
+	defer b.Enter("Number").Exit(&ok)
+
+	return b.MatchFunc(func(token rd.Token) bool {
+		s, ok := token.(string)
+		return ok && regexp.MustCompile(`^(\d*\.\d+|\d+)$`).MatchString(s)
+	}, "number")
+}
+
+func TestParse(t *testing.T) {
+	g, err := Parse(pl0Grammar)
+	if !assert.NoError(t, err) {
+		return
+	}
+	expected := `program = block "." .
+block = ["const" ident "=" number {"," ident "=" number} ";"] ["var" ident {"," ident} ";"] {"procedure" ident ";" block ";"} statement .
+statement = ident ":=" expression | "!" expression | "?" ident | "call" ident | "begin" statement {";" statement} "end" | "if" condition "then" statement | "while" condition "do" statement .
+condition = "odd" expression | expression ("=" | "#" | "<" | "<=" | ">" | ">=") expression .
+expression = ["+" | "-"] term {("+" | "-") term} .
+term = factor {("*" | "/") factor} .
+factor = ident | number | "(" expression ")" .
+`
+	assert.Equal(t, expected, g.String())
+	assert.Equal(t, rd.Pos{Offset: 2, Line: 2, Column: 2}, g.Rules[0].Span.StartPos)
+
+	g, err = Parse(`Expr' = "+" Expr | 'ε' | ε`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, Alternation{Sequence{Terminal("+"), NonTerminal("Expr")}, Terminal("ε"), Empty{}}, g.Rule("Expr'").Expr)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		grammar string
+		err     string
+	}{
+		{"a = (b", "1:7: expected name, terminal, `ε`, `(`, `[`, `{`, `|` or `)`, found end of grammar"},
+		{"a = b\n  c = ]", "2:7: expected name, terminal, `ε`, `(`, `[`, `{`, `|` or `.`, found `]`"},
+		{"a = b .\na = c .", "2:1: rule a redeclared"},
+		{`a = "b`, "1:5: unterminated or empty terminal"},
+		{"a = b ; c", "1:7: invalid character ';'"},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.grammar)
+		assert.EqualError(t, err, test.err, test.grammar)
+	}
+}
+
+func TestInterpreter(t *testing.T) {
+	in := &Interpreter{
+		Grammar: MustParse(arithmeticGrammar),
+		Rules:   map[string]func(b *rd.Builder) bool{"Number": number},
+	}
+	tokens := []rd.Token{"2", "*", "(", "3", "-", "1", ")"}
+	parseTree, _, err := in.Parse(tokens, "Expr")
+	if !assert.NoError(t, err) {
+		return
+	}
+	expected := `Expr
+└─ Term
+   ├─ Factor
+   │  └─ Number
+   │     └─ 2
+   ├─ *
+   └─ Term
+      └─ Factor
+         ├─ (
+         ├─ Expr
+         │  ├─ Term
+         │  │  └─ Factor
+         │  │     └─ Number
+         │  │        └─ 3
+         │  ├─ -
+         │  └─ Expr
+         │     └─ Term
+         │        └─ Factor
+         │           └─ Number
+         │              └─ 1
+         └─ )
+`
+	assert.Equal(t, expected, parseTree.String())
+
+	_, _, err = in.Parse([]rd.Token{"2", "*"}, "Expr")
+	assert.EqualError(t, err, "unexpected `*` after Number at token 1")
+}
+
+func TestInterpreter_PL0(t *testing.T) {
+	ident := regexp.MustCompile(`^[[:alpha:]]\w*$`)
+	in := &Interpreter{
+		Grammar: MustParse(pl0Grammar),
+		Rules: map[string]func(b *rd.Builder) bool{
+			"ident": func(b *rd.Builder) bool {
+				return b.MatchFunc(func(token rd.Token) bool {
+					s := token.(string)
+					return ident.MatchString(s) && s != "end" && s != "begin"
+				}, "ident")
+			},
+			"number": number,
+		},
+	}
+	tokens := []rd.Token{"var", "x", ";", "begin", "x", ":=", "1", ";", "!", "x", "end", "."}
+	parseTree, _, err := in.Parse(tokens, "program")
+	if !assert.NoError(t, err) {
+		return
+	}
+	expected := `program
+├─ block
+│  ├─ var
+│  ├─ x
+│  ├─ ;
+│  └─ statement
+│     ├─ begin
+│     ├─ statement
+│     │  ├─ x
+│     │  ├─ :=
+│     │  └─ expression
+│     │     └─ term
+│     │        └─ factor
+│     │           └─ Number
+│     │              └─ 1
+│     ├─ ;
+│     ├─ statement
+│     │  ├─ !
+│     │  └─ expression
+│     │     └─ term
+│     │        └─ factor
+│     │           └─ x
+│     └─ end
+└─ .
+`
+	assert.Equal(t, expected, parseTree.String())
+}
+
+func TestInterpreter_LeftRecursion(t *testing.T) {
+	in := &Interpreter{
+		Grammar: MustParse(`Expr = Expr "-" Number | Number .`),
+		Rules:   map[string]func(b *rd.Builder) bool{"Number": number},
+	}
+	parseTree, _, err := in.Parse([]rd.Token{"3", "-", "2", "-", "1"}, "Expr", rd.Memoize())
+	if !assert.NoError(t, err) {
+		return
+	}
+	expected := `Expr
+├─ Expr
+│  ├─ Expr
+│  │  └─ Number
+│  │     └─ 3
+│  ├─ -
+│  └─ Number
+│     └─ 2
+├─ -
+└─ Number
+   └─ 1
+`
+	assert.Equal(t, expected, parseTree.String())
+}
+
+func TestInterpreter_NullableStart(t *testing.T) {
+	in := &Interpreter{Grammar: MustParse(`S = {"a"} . A = "x" [S] .`)}
+	parseTree, _, err := in.Parse([]rd.Token{"b"}, "S")
+	assert.Nil(t, parseTree)
+	assert.EqualError(t, err, "unexpected `b` at token 0")
+
+	parseTree, _, err = in.Parse(nil, "S")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "S\n", parseTree.String())
+	}
+	parseTree, _, err = in.Parse([]rd.Token{"a", "a"}, "S")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "S\n├─ a\n└─ a\n", parseTree.String())
+	}
+	// S is skipped when it isn't the root
+	parseTree, _, err = in.Parse([]rd.Token{"x"}, "A")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "A\n└─ x\n", parseTree.String())
+	}
+}
+
+func TestInterpreter_Check(t *testing.T) {
+	in := &Interpreter{Grammar: MustParse(arithmeticGrammar)}
+	assert.EqualError(t, in.Check(), "rule Factor references undefined rule Number")
+}
+
+func TestInterpreter_DeclareCoverage(t *testing.T) {
+	in := &Interpreter{
+		Grammar: MustParse(arithmeticGrammar),
+		Rules:   map[string]func(b *rd.Builder) bool{"Number": number},
+	}
+	c := rd.NewCoverage()
+	in.DeclareCoverage(c)
+	_, _, err := in.Parse([]rd.Token{"2", "*", "3"}, "Expr", rd.Trace(c))
+	assert.NoError(t, err)
+
+	var uncovered []interface{}
+	for _, nc := range c.NonTerms() {
+		if nc.Succeeded == 0 {
+			uncovered = append(uncovered, nc.NonTerm)
+		}
+	}
+	for _, mc := range c.Matches() {
+		if mc.Matched == 0 {
+			uncovered = append(uncovered, mc.Want)
+		}
+	}
+	assert.Equal(t, []interface{}{"+", "-", "/", "(", ")"}, uncovered)
+
+	uncovered = nil
+	for _, ac := range c.Alternatives() {
+		if ac.Succeeded == 0 {
+			uncovered = append(uncovered, ac.NonTerm.(string)+": "+ac.Alt)
+		}
+	}
+	assert.Equal(t, []interface{}{
+		`Expr: Term "+" Expr`,
+		`Expr: Term "-" Expr`,
+		`Term: Factor "/" Term`,
+		`Factor: "(" Expr ")"`,
+		`Factor: "-" Factor`,
+	}, uncovered)
+	assert.Contains(t, c.Alternatives(), rd.AltCoverage{NonTerm: "Term", Alt: `Factor "*" Term`, Tried: 6, Succeeded: 3})
+}
@@ -0,0 +1,167 @@
+package ebnf
+
+import (
+	"fmt"
+
+	"github.com/shivamMg/rd"
+)
+
+// Interpreter parses tokens using a Grammar, without a Go function per rule. It
+// builds the same parse tree and debug tree as a hand-written parser that uses
+// the rule names as non-terminals, and Match for terminals.
+//
+// Alternatives are tried in order, and the first one that matches is chosen.
+// Options and repetitions match as much as they can. Rules that match without
+// consuming tokens are skipped (see rd.Builder's Skip method), like null
+// productions usually are in hand-written parsers, except for the start rule
+// passed to Parse, which is the root of the parse tree. Rule bodies are memoized (see
+// rd.Builder's Memo method), so left-recursive grammars can be interpreted by
+// passing rd.Memoize() to Parse. Each alternative tried is reported to tracers
+// using rd.Builder's Alternative method, labelled with its EBNF (see Expr's String
+// method), so that rd.Coverage can tell which ones were never exercised.
+type Interpreter struct {
+	Grammar *Grammar
+	// Terminal returns the token a terminal matches. If nil, terminals match
+	// tokens equal to their text (as strings).
+	Terminal func(terminal string) rd.Token
+	// Rules contains Go functions for rules not defined in Grammar, ex. a rule
+	// matching identifiers. They override rules in Grammar with the same name.
+	Rules map[string]func(b *rd.Builder) bool
+}
+
+// Parse parses tokens starting with the rule named start. opts are passed to
+// rd.NewBuilder.
+func (in *Interpreter) Parse(tokens []rd.Token, start string, opts ...rd.Option) (parseTree *rd.Tree, debugTree *rd.DebugTree, err error) {
+	if err := in.Check(); err != nil {
+		return nil, nil, err
+	}
+	if in.Grammar.Rule(start) == nil && in.Rules[start] == nil {
+		return nil, nil, fmt.Errorf("undefined start rule %s", start)
+	}
+	b := rd.NewBuilder(tokens, opts...)
+	in.rule(b, start, true)
+	return b.ParseTree(), b.DebugTree(), b.Err()
+}
+
+// Check returns an error if Grammar references a rule that's neither defined in
+// Grammar nor in Rules.
+func (in *Interpreter) Check() error {
+	for _, r := range in.Grammar.Rules {
+		var err error
+		Walk(r.Expr, func(e Expr) {
+			if n, ok := e.(NonTerminal); ok && err == nil && in.Grammar.Rule(string(n)) == nil && in.Rules[string(n)] == nil {
+				err = fmt.Errorf("rule %s references undefined rule %s", r.Name, n)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeclareCoverage declares Grammar's rules, alternatives and terminals in c (see
+// rd.Coverage), so that the ones never exercised are reported.
+func (in *Interpreter) DeclareCoverage(c *rd.Coverage) {
+	for _, r := range in.Grammar.Rules {
+		c.Declare(r.Name)
+	}
+	for _, r := range in.Grammar.Rules {
+		Walk(r.Expr, func(e Expr) {
+			if a, ok := e.(Alternation); ok {
+				for _, alt := range a {
+					c.DeclareAlternatives(r.Name, alt.String())
+				}
+			}
+		})
+	}
+	seen := map[Terminal]bool{}
+	for _, r := range in.Grammar.Rules {
+		Walk(r.Expr, func(e Expr) {
+			if t, ok := e.(Terminal); ok && !seen[t] {
+				seen[t] = true
+				c.DeclareMatches(in.terminal(t))
+			}
+		})
+	}
+}
+
+// Rule parses the rule named name using b. It's a non-terminal function for the
+// rule, and can be called from hand-written non-terminal functions.
+func (in *Interpreter) Rule(b *rd.Builder, name string) bool {
+	return in.rule(b, name, false)
+}
+
+// rule parses the rule named name. It isn't skipped if it's the root
+// non-terminal, since skipping the root leaves b without a parse tree or an error.
+func (in *Interpreter) rule(b *rd.Builder, name string, root bool) (ok bool) {
+	if f, ok := in.Rules[name]; ok {
+		return f(b)
+	}
+	r := in.Grammar.Rule(name)
+	if r == nil {
+		panic("ebnf: undefined rule " + name)
+	}
+
+	defer b.Enter(name).Exit(&ok)
+	return b.Memo(func() bool {
+		start := b.Mark()
+		if !in.eval(b, r.Expr) {
+			return false
+		}
+		if !root && b.Mark() == start {
+			b.Skip()
+		}
+		return true
+	})
+}
+
+// terminal returns the token t matches.
+func (in *Interpreter) terminal(t Terminal) rd.Token {
+	if in.Terminal == nil {
+		return string(t)
+	}
+	return in.Terminal(string(t))
+}
+
+// eval matches e. In case of a non-match, the current index and the parse tree
+// are restored.
+func (in *Interpreter) eval(b *rd.Builder, e Expr) bool {
+	switch e := e.(type) {
+	case Alternation:
+		for _, alt := range e {
+			if b.Alternative(alt.String(), in.eval(b, alt)) {
+				return true
+			}
+		}
+		return false
+	case Sequence:
+		m := b.Mark()
+		for _, item := range e {
+			if !in.eval(b, item) {
+				b.Reset(m)
+				return false
+			}
+		}
+		return true
+	case Group:
+		return in.eval(b, e.Expr)
+	case Option:
+		in.eval(b, e.Expr)
+		return true
+	case Repetition:
+		for {
+			m := b.Mark()
+			if !in.eval(b, e.Expr) || b.Mark() == m {
+				return true
+			}
+		}
+	case Terminal:
+		return b.Match(in.terminal(e))
+	case NonTerminal:
+		return in.Rule(b, string(e))
+	case Empty:
+		return true
+	}
+	panic(fmt.Sprintf("ebnf: unknown expression %T", e))
+}
@@ -0,0 +1,296 @@
+package ebnf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shivamMg/rd"
+)
+
+// Error is an error in the grammar text, returned by Parse.
+type Error struct {
+	Pos rd.Pos
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %s", e.Pos, e.Msg)
+}
+
+// token kinds other than punctuation, which is its own kind
+const (
+	kindName     = "name"
+	kindTerminal = "terminal"
+)
+
+type token struct {
+	kind     string
+	text     string // as it appears in the grammar text
+	pos, end rd.Pos
+}
+
+func (t token) Kind() string {
+	return t.kind
+}
+
+func (t token) Pos() rd.Pos {
+	return t.pos
+}
+
+func (t token) End() rd.Pos {
+	return t.end
+}
+
+func (t token) String() string {
+	return t.text
+}
+
+func isNameStart(r rune) bool {
+	return unicode.IsLetter(r) && r != 'ε' || r == '_'
+}
+
+func isNamePart(r rune) bool {
+	return isNameStart(r) || unicode.IsDigit(r) || r == '\'' || r == '-'
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	pos := rd.Pos{Offset: 0, Line: 1, Column: 1}
+	advance := func(n int) {
+		for _, r := range src[pos.Offset : pos.Offset+n] {
+			if r == '\n' {
+				pos.Line++
+				pos.Column = 1
+			} else {
+				pos.Column += utf8.RuneLen(r)
+			}
+		}
+		pos.Offset += n
+	}
+
+	for pos.Offset < len(src) {
+		rest := src[pos.Offset:]
+		r, size := utf8.DecodeRuneInString(rest)
+		start := pos
+		var kind string
+		switch {
+		case unicode.IsSpace(r):
+			advance(size)
+			continue
+		case strings.ContainsRune("=|.()[]{}ε", r):
+			kind = string(r)
+		case r == '"' || r == '\'':
+			end := strings.IndexRune(rest[size:], r)
+			if end <= 0 || strings.ContainsRune(rest[size:size+end], '\n') {
+				return nil, &Error{Pos: start, Msg: "unterminated or empty terminal"}
+			}
+			kind, size = kindTerminal, size+end+1
+		case isNameStart(r):
+			kind = kindName
+			for _, r := range rest[size:] {
+				if !isNamePart(r) {
+					break
+				}
+				size += utf8.RuneLen(r)
+			}
+		default:
+			return nil, &Error{Pos: start, Msg: fmt.Sprintf("invalid character %q", r)}
+		}
+		advance(size)
+		tokens = append(tokens, token{kind: kind, text: rest[:size], pos: start, end: pos})
+	}
+	return tokens, nil
+}
+
+// Parse parses an EBNF grammar.
+func Parse(src string) (*Grammar, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	b := rd.NewBuilderOf(tokens)
+	grammar(b)
+	if err := b.Err(); err != nil {
+		return nil, toError(tokens, err)
+	}
+
+	g := &Grammar{}
+	for _, production := range b.ParseTree().Subtrees {
+		name, _ := production.Subtrees[0].Token()
+		if g.Rule(name.text) != nil {
+			return nil, &Error{Pos: name.pos, Msg: fmt.Sprintf("rule %s redeclared", name.text)}
+		}
+		g.Rules = append(g.Rules, &Rule{
+			Name: name.text,
+			Expr: buildExpression(production.Subtrees[2]),
+			Span: production.Span,
+		})
+	}
+	return g, nil
+}
+
+// MustParse is like Parse but panics if the grammar can't be parsed. It's
+// helpful for grammars declared as constants.
+func MustParse(src string) *Grammar {
+	g, err := Parse(src)
+	if err != nil {
+		panic("ebnf: " + err.Error())
+	}
+	return g
+}
+
+// toError converts a parsing error from rd to an *Error.
+func toError(tokens []token, err error) *Error {
+	at := func(i int) (rd.Pos, string) {
+		if i < len(tokens) {
+			return tokens[i].pos, fmt.Sprintf("`%s`", tokens[i].text)
+		}
+		if len(tokens) == 0 {
+			return rd.Pos{Line: 1, Column: 1}, "end of grammar"
+		}
+		return tokens[len(tokens)-1].end, "end of grammar"
+	}
+
+	var pe *rd.ParsingError
+	if errors.As(err, &pe) {
+		pos, found := at(pe.Index)
+		expected := make([]string, len(pe.Expected))
+		for i, e := range pe.Expected {
+			switch kind := string(e.(rd.Label)); kind {
+			case kindName, kindTerminal:
+				expected[i] = kind
+			default:
+				expected[i] = "`" + kind + "`"
+			}
+		}
+		msg := "unexpected " + found
+		if n := len(expected); n > 1 {
+			msg = fmt.Sprintf("expected %s or %s, found %s", strings.Join(expected[:n-1], ", "), expected[n-1], found)
+		} else if n == 1 {
+			msg = fmt.Sprintf("expected %s, found %s", expected[0], found)
+		}
+		return &Error{Pos: pos, Msg: msg}
+	}
+	var nce *rd.NotConsumedError
+	if errors.As(err, &nce) {
+		pos, found := at(nce.Index)
+		return &Error{Pos: pos, Msg: "unexpected " + found}
+	}
+	return &Error{Msg: err.Error()}
+}
+
+// grammar parsing functions. Terms that are names followed by "=" start the next
+// production, which is how productions without a terminating period end.
+
+func grammar(b *rd.BuilderOf[token]) (ok bool) {
+	defer b.Enter("grammar").Exit(&ok)
+
+	for {
+		if _, ok := b.Peek(1); !ok {
+			return true
+		}
+		if !production(b) {
+			return false
+		}
+	}
+}
+
+func production(b *rd.BuilderOf[token]) (ok bool) {
+	defer b.Enter("production").Exit(&ok)
+
+	if !(b.MatchKind(kindName) && b.MatchKind("=") && expression(b)) {
+		return false
+	}
+	b.MatchKind(".")
+	return true
+}
+
+func expression(b *rd.BuilderOf[token]) (ok bool) {
+	defer b.Enter("expression").Exit(&ok)
+
+	if !sequence(b) {
+		return false
+	}
+	for b.MatchKind("|") {
+		if !sequence(b) {
+			return false
+		}
+	}
+	return true
+}
+
+func sequence(b *rd.BuilderOf[token]) (ok bool) {
+	defer b.Enter("sequence").Exit(&ok)
+
+	for term(b) {
+	}
+	return true
+}
+
+func term(b *rd.BuilderOf[token]) (ok bool) {
+	defer b.Enter("term").Exit(&ok)
+
+	if next, ok := b.Peek(1); ok && next.kind == kindName {
+		if after, ok := b.Peek(2); ok && after.kind == "=" {
+			return false
+		}
+	}
+	switch {
+	case b.MatchKind(kindName), b.MatchKind(kindTerminal), b.MatchKind("ε"):
+		return true
+	case b.MatchKind("("):
+		return expression(b) && b.MatchKind(")")
+	case b.MatchKind("["):
+		return expression(b) && b.MatchKind("]")
+	case b.MatchKind("{"):
+		return expression(b) && b.MatchKind("}")
+	}
+	return false
+}
+
+func buildExpression(t *rd.TreeOf[token]) Expr {
+	var alts Alternation
+	for _, st := range t.Subtrees {
+		if _, ok := st.Token(); !ok {
+			alts = append(alts, buildSequence(st))
+		}
+	}
+	if len(alts) == 1 {
+		return alts[0]
+	}
+	return alts
+}
+
+func buildSequence(t *rd.TreeOf[token]) Expr {
+	var seq Sequence
+	for _, st := range t.Subtrees {
+		seq = append(seq, buildTerm(st))
+	}
+	switch len(seq) {
+	case 0:
+		return Empty{}
+	case 1:
+		return seq[0]
+	}
+	return seq
+}
+
+func buildTerm(t *rd.TreeOf[token]) Expr {
+	first, _ := t.Subtrees[0].Token()
+	switch first.kind {
+	case kindName:
+		return NonTerminal(first.text)
+	case kindTerminal:
+		return Terminal(first.text[1 : len(first.text)-1])
+	case "ε":
+		return Empty{}
+	case "(":
+		return Group{buildExpression(t.Subtrees[1])}
+	case "[":
+		return Option{buildExpression(t.Subtrees[1])}
+	}
+	return Repetition{buildExpression(t.Subtrees[1])}
+}
@@ -0,0 +1,293 @@
+// Package railroad renders rules of EBNF grammars parsed by package ebnf as SVG
+// railroad diagrams. Terminals are drawn in rounded boxes, and references to
+// other rules in square boxes. Alternatives branch off the main line, options
+// can be bypassed, and repetitions loop back.
+package railroad
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/shivamMg/rd/ebnf"
+)
+
+const (
+	// arc radius, also used as the gap between items
+	radius = 10.0
+	// height of boxes, and width of a character in them
+	boxHeight = 22.0
+	charWidth = 8.0
+	// padding around a diagram
+	padding = 20.0
+)
+
+// diagram is a part of a railroad diagram. It's entered from the left and
+// exited from the right, at the same height: y. It spans up above y, and down
+// below it.
+type diagram interface {
+	size() (width, up, down float64)
+	render(w *bytes.Buffer, x, y float64)
+}
+
+type box struct {
+	text    string
+	rounded bool
+	// href links the box, if not empty
+	href string
+}
+
+func (b box) size() (float64, float64, float64) {
+	return float64(utf8.RuneCountInString(b.text))*charWidth + 2*radius, boxHeight / 2, boxHeight / 2
+}
+
+func (b box) render(w *bytes.Buffer, x, y float64) {
+	width, up, _ := b.size()
+	class, rx := "nonterminal", 0.0
+	if b.rounded {
+		class, rx = "terminal", boxHeight/2
+	}
+	if b.href != "" {
+		fmt.Fprintf(w, `<a href="%s">`, html.EscapeString(b.href))
+	}
+	fmt.Fprintf(w, `<g class="%s"><rect x="%g" y="%g" width="%g" height="%g" rx="%g"/>`, class, x, y-up, width, boxHeight, rx)
+	fmt.Fprintf(w, `<text x="%g" y="%g">%s</text></g>`, x+width/2, y+4, html.EscapeString(b.text))
+	if b.href != "" {
+		w.WriteString(`</a>`)
+	}
+	w.WriteString("\n")
+}
+
+// skip is an empty line.
+type skip struct{}
+
+func (skip) size() (float64, float64, float64) {
+	return 0, 0, 0
+}
+
+func (skip) render(w *bytes.Buffer, x, y float64) {}
+
+type sequence []diagram
+
+func (s sequence) size() (width, up, down float64) {
+	for i, d := range s {
+		dw, du, dd := d.size()
+		if i > 0 {
+			width += radius
+		}
+		width, up, down = width+dw, max(up, du), max(down, dd)
+	}
+	return width, up, down
+}
+
+func (s sequence) render(w *bytes.Buffer, x, y float64) {
+	for i, d := range s {
+		if i > 0 {
+			line(w, x, y, x+radius)
+			x += radius
+		}
+		d.render(w, x, y)
+		dw, _, _ := d.size()
+		x += dw
+	}
+}
+
+// choice stacks alternatives below the first one, which is on the main line.
+type choice []diagram
+
+// offsets returns the height of each alternative below y.
+func (c choice) offsets() []float64 {
+	offsets := make([]float64, len(c))
+	_, _, down := c[0].size()
+	for i, d := range c[1:] {
+		_, du, dd := d.size()
+		offsets[i+1] = offsets[i] + max(down+radius+du, 2*radius)
+		down = dd
+	}
+	return offsets
+}
+
+func (c choice) size() (width, up, down float64) {
+	for _, d := range c {
+		width = max(width, widthOf(d))
+	}
+	_, up, down = c[0].size()
+	if len(c) > 1 {
+		_, _, lastDown := c[len(c)-1].size()
+		offsets := c.offsets()
+		down = max(down, offsets[len(offsets)-1]+lastDown)
+	}
+	return width + 4*radius, up, down
+}
+
+func (c choice) render(w *bytes.Buffer, x, y float64) {
+	width, _, _ := c.size()
+	offsets := c.offsets()
+	for i, d := range c {
+		dy := offsets[i]
+		if i == 0 {
+			line(w, x, y, x+2*radius)
+		} else {
+			fmt.Fprintf(w, `<path d="M%g %g a%g %g 0 0 1 %g %g v%g a%g %g 0 0 0 %g %g"/>`+"\n",
+				x, y, radius, radius, radius, radius, dy-2*radius, radius, radius, radius, radius)
+		}
+		d.render(w, x+2*radius, y+dy)
+		line(w, x+2*radius+widthOf(d), y+dy, x+width-2*radius)
+		if i == 0 {
+			line(w, x+width-2*radius, y, x+width)
+		} else {
+			fmt.Fprintf(w, `<path d="M%g %g a%g %g 0 0 0 %g %g v%g a%g %g 0 0 1 %g %g"/>`+"\n",
+				x+width-2*radius, y+dy, radius, radius, radius, -radius, -(dy - 2*radius), radius, radius, radius, -radius)
+		}
+	}
+}
+
+// loop is one or more repetitions of a diagram, returning below it.
+type loop struct {
+	d diagram
+}
+
+func (l loop) returnOffset() float64 {
+	_, _, down := l.d.size()
+	return max(down+radius, 2*radius)
+}
+
+func (l loop) size() (float64, float64, float64) {
+	width, up, _ := l.d.size()
+	return width + 2*radius, up, l.returnOffset()
+}
+
+func (l loop) render(w *bytes.Buffer, x, y float64) {
+	width, _, _ := l.size()
+	line(w, x, y, x+radius)
+	l.d.render(w, x+radius, y)
+	line(w, x+radius+widthOf(l.d), y, x+width)
+	dy := l.returnOffset()
+	fmt.Fprintf(w, `<path d="M%g %g a%g %g 0 0 1 %g %g v%g a%g %g 0 0 1 %g %g h%g a%g %g 0 0 1 %g %g v%g a%g %g 0 0 1 %g %g"/>`+"\n",
+		x+width-radius, y, radius, radius, radius, radius, dy-2*radius, radius, radius, -radius, radius,
+		-(width - 2*radius), radius, radius, -radius, -radius, -(dy - 2*radius), radius, radius, radius, -radius)
+}
+
+func widthOf(d diagram) float64 {
+	width, _, _ := d.size()
+	return width
+}
+
+func max(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func line(w *bytes.Buffer, x1, y, x2 float64) {
+	if x2 > x1 {
+		fmt.Fprintf(w, `<path d="M%g %g h%g"/>`+"\n", x1, y, x2-x1)
+	}
+}
+
+// build returns the diagram for e. Rules defined in g are linked to using href.
+func build(g *ebnf.Grammar, e ebnf.Expr, href func(rule string) string) diagram {
+	switch e := e.(type) {
+	case ebnf.Terminal:
+		return box{text: string(e), rounded: true}
+	case ebnf.NonTerminal:
+		b := box{text: string(e)}
+		if g.Rule(string(e)) != nil && href != nil {
+			b.href = href(string(e))
+		}
+		return b
+	case ebnf.Empty:
+		return skip{}
+	case ebnf.Group:
+		return build(g, e.Expr, href)
+	case ebnf.Sequence:
+		s := make(sequence, len(e))
+		for i, item := range e {
+			s[i] = build(g, item, href)
+		}
+		return s
+	case ebnf.Alternation:
+		c := make(choice, len(e))
+		for i, alt := range e {
+			c[i] = build(g, alt, href)
+		}
+		return c
+	case ebnf.Option:
+		return choice{skip{}, build(g, e.Expr, href)}
+	case ebnf.Repetition:
+		return choice{skip{}, loop{build(g, e.Expr, href)}}
+	}
+	panic(fmt.Sprintf("railroad: unknown expression %T", e))
+}
+
+// SVG writes a self-contained SVG image of the railroad diagram for r, styled by
+// an embedded stylesheet. References to rules defined in g are linked to using
+// href, unless it's nil.
+func SVG(w io.Writer, g *ebnf.Grammar, r *ebnf.Rule, href func(rule string) string) error {
+	var buf bytes.Buffer
+	writeSVG(&buf, build(g, r.Expr, href), true)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// writeSVG writes the SVG image of d, with svgStyle if standalone.
+func writeSVG(w *bytes.Buffer, d diagram, standalone bool) {
+	width, up, down := d.size()
+	// the diagram starts and ends with a bar, connected to it by a line
+	width += 2 * (padding + radius)
+	height := up + down + 2*padding
+	x, y := padding, padding+up
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" class="railroad" width="%g" height="%g" viewBox="0 0 %g %g">`+"\n", width, height, width, height)
+	if standalone {
+		fmt.Fprintf(w, "<style>%s</style>\n", svgStyle)
+	}
+	fmt.Fprintf(w, `<path d="M%g %g v%g"/>`+"\n", x, y-radius/2, radius)
+	line(w, x, y, x+radius)
+	d.render(w, x+radius, y)
+	line(w, x+radius+widthOf(d), y, width-padding)
+	fmt.Fprintf(w, `<path d="M%g %g v%g"/>`+"\n", width-padding, y-radius/2, radius)
+	w.WriteString("</svg>\n")
+}
+
+// Anchor returns the id of the HTML element for rule in pages written by HTML.
+func Anchor(rule string) string {
+	return "rule-" + url.PathEscape(rule)
+}
+
+const svgStyle = `
+svg.railroad path { stroke: #333; stroke-width: 2; fill: none; }
+svg.railroad rect { stroke: #333; stroke-width: 2; }
+svg.railroad .terminal rect { fill: #dfd; }
+svg.railroad .nonterminal rect { fill: #ddf; }
+svg.railroad a:hover rect { fill: #bbf; }
+svg.railroad text { font-family: monospace; font-size: 14px; text-anchor: middle; }
+`
+
+const pageStyle = `
+body { font-family: sans-serif; margin: 2em; }
+pre { background: #f6f6f6; padding: 0.5em; }
+` + svgStyle
+
+// HTML writes a self-contained HTML page with a railroad diagram for each rule
+// of g, along with the rule in EBNF. Boxes for rules link to their diagrams.
+func HTML(w io.Writer, g *ebnf.Grammar, title string) error {
+	var buf bytes.Buffer
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&buf, "<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n", html.EscapeString(title), pageStyle)
+	fmt.Fprintf(&buf, "<h1>%s</h1>\n", html.EscapeString(title))
+	href := func(rule string) string {
+		return "#" + Anchor(rule)
+	}
+	for _, r := range g.Rules {
+		fmt.Fprintf(&buf, "<h2 id=\"%s\">%s</h2>\n", html.EscapeString(Anchor(r.Name)), html.EscapeString(r.Name))
+		fmt.Fprintf(&buf, "<pre>%s</pre>\n", html.EscapeString(r.String()))
+		writeSVG(&buf, build(g, r.Expr, href), false)
+	}
+	buf.WriteString("</body>\n</html>\n")
+	_, err := buf.WriteTo(w)
+	return err
+}
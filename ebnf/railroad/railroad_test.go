@@ -0,0 +1,84 @@
+package railroad
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/shivamMg/rd/ebnf"
+	"github.com/stretchr/testify/assert"
+)
+
+// wellFormed returns an error if s isn't well-formed XML.
+func wellFormed(s string) error {
+	d := xml.NewDecoder(strings.NewReader(s))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func TestSVG(t *testing.T) {
+	g := ebnf.MustParse(`
+		List = Item {"," Item} .
+		Item = "<" ["-"] number ">" | List .
+	`)
+	var buf bytes.Buffer
+	if !assert.NoError(t, SVG(&buf, g, g.Rule("Item"), func(rule string) string { return rule + ".svg" })) {
+		return
+	}
+	svg := buf.String()
+	assert.NoError(t, wellFormed(svg))
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" class="railroad" width="322" height="115"`), svg)
+	assert.Equal(t, 3, strings.Count(svg, `<g class="terminal">`))
+	assert.Contains(t, svg, `<rect x="50" y="20" width="28" height="22" rx="11"/><text x="64" y="35">&lt;</text>`)
+	// number isn't defined, so only List is linked
+	assert.Equal(t, 2, strings.Count(svg, `<g class="nonterminal">`))
+	assert.Equal(t, 1, strings.Count(svg, `<a href="List.svg">`))
+	// standalone images carry their own style
+	assert.Contains(t, svg, "<style>"+svgStyle+"</style>")
+	assert.Contains(t, svg, "svg.railroad path { stroke: #333; stroke-width: 2; fill: none; }")
+}
+
+func TestSize(t *testing.T) {
+	g := ebnf.MustParse(`A = "ab" ["c"] {"d" | E} .`)
+	d := build(g, g.Rule("A").Expr, nil)
+	terminal := func(n float64) float64 { return n*charWidth + 2*radius }
+	loopWidth := terminal(1) + 4*radius + 2*radius
+	width, up, down := d.size()
+	assert.Equal(t, terminal(2)+radius+terminal(1)+4*radius+radius+loopWidth+4*radius, width)
+	assert.Equal(t, boxHeight/2, up)
+	// the repetition's loop is below the line skipping it. E is below "d", and
+	// the loop returns below E
+	loopDown := (boxHeight/2 + radius + boxHeight/2) + boxHeight/2 + radius
+	assert.Equal(t, radius+boxHeight/2+loopDown, down)
+}
+
+func TestHTML(t *testing.T) {
+	g := ebnf.MustParse(`
+		Expr' = "+" Term Expr' | ε .
+		Term  = "x" .
+	`)
+	var buf bytes.Buffer
+	if !assert.NoError(t, HTML(&buf, g, "Expr & Term")) {
+		return
+	}
+	page := buf.String()
+	for _, svg := range strings.SplitAfter(page, "</svg>\n")[:2] {
+		assert.NoError(t, wellFormed(svg[strings.Index(svg, "<svg "):]))
+	}
+	assert.Contains(t, page, `<title>Expr &amp; Term</title>`)
+	assert.Contains(t, page, `<h2 id="rule-Expr%27">Expr&#39;</h2>`)
+	assert.Contains(t, page, `<pre>Expr&#39; = &#34;+&#34; Term Expr&#39; | ε .</pre>`)
+	assert.Contains(t, page, `<a href="#rule-Term">`)
+	assert.Contains(t, page, `<a href="#rule-Expr%27">`)
+	assert.Equal(t, 2, strings.Count(page, "<svg "))
+	assert.Equal(t, 1, strings.Count(page, "<style>"))
+}
@@ -0,0 +1,291 @@
+// Package sentence generates random sentences of EBNF grammars parsed by package
+// ebnf: token sequences that match the grammar. Along with mutated sentences,
+// which likely don't, they can be fed to parsers built using rd, ex. from a fuzz
+// test:
+//
+//	func FuzzParse(f *testing.F) {
+//		f.Add(int64(1))
+//		f.Fuzz(func(t *testing.T, seed int64) {
+//			g := &sentence.Generator{Grammar: grammar, Rand: rand.New(rand.NewSource(seed))}
+//			tokens, err := g.Generate()
+//			if err != nil {
+//				t.Fatal(err)
+//			}
+//			if _, _, err := Parse(tokens); err != nil {
+//				t.Errorf("valid sentence %v not parsed: %v", tokens, err)
+//			}
+//			Parse(g.Mutate(tokens)) // mustn't panic
+//		})
+//	}
+package sentence
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/shivamMg/rd"
+	"github.com/shivamMg/rd/ebnf"
+)
+
+const (
+	defaultMaxDepth  = 20
+	defaultMaxTokens = 100
+	defaultMaxRepeat = 3
+)
+
+// Generator generates random sentences of a grammar.
+//
+// Alternatives are chosen by coverage: among the alternatives of an alternation,
+// one of those chosen the least number of times so far is chosen, so that
+// successive sentences cover all of them. Once a sentence is MaxDepth rules deep,
+// or has MaxTokens tokens, the shortest ways to finish it are chosen: options
+// and repetitions are left out, and alternatives that nest the least number of
+// rules are chosen.
+type Generator struct {
+	Grammar *ebnf.Grammar
+	// Start is the rule sentences are generated for. If empty, it's the first
+	// rule.
+	Start string
+	// Terminal returns the token for a terminal. If nil, terminals are
+	// generated as strings.
+	Terminal func(terminal string) rd.Token
+	// Rules generate tokens for rules not defined in Grammar, ex. a rule matching
+	// identifiers.
+	Rules map[string]func(r *rand.Rand) []rd.Token
+	// Rand is the source of randomness. If nil, a source seeded with 1 is used.
+	Rand *rand.Rand
+	// MaxDepth defaults to 20, MaxTokens to 100, and MaxRepeat, the maximum
+	// number of times a repetition is repeated, to 3. MaxDepth and MaxTokens are
+	// soft limits: sentences exceed them by as much as needed to be finished.
+	MaxDepth, MaxTokens, MaxRepeat int
+
+	// heights are the minimum number of nested rules needed to finish a rule
+	heights  map[string]int
+	coverage map[*ebnf.Expr][]int
+	initErr  error
+}
+
+func (g *Generator) init() error {
+	if g.heights != nil || g.initErr != nil {
+		return g.initErr
+	}
+	if g.Start == "" && len(g.Grammar.Rules) > 0 {
+		g.Start = g.Grammar.Rules[0].Name
+	}
+	if g.Rand == nil {
+		g.Rand = rand.New(rand.NewSource(1))
+	}
+	if g.MaxDepth == 0 {
+		g.MaxDepth = defaultMaxDepth
+	}
+	if g.MaxTokens == 0 {
+		g.MaxTokens = defaultMaxTokens
+	}
+	if g.MaxRepeat == 0 {
+		g.MaxRepeat = defaultMaxRepeat
+	}
+	g.coverage = map[*ebnf.Expr][]int{}
+
+	for _, r := range g.Grammar.Rules {
+		ebnf.Walk(r.Expr, func(e ebnf.Expr) {
+			if n, ok := e.(ebnf.NonTerminal); ok && g.initErr == nil && g.Grammar.Rule(string(n)) == nil && g.Rules[string(n)] == nil {
+				g.initErr = fmt.Errorf("rule %s references undefined rule %s", r.Name, n)
+			}
+			if alt, ok := e.(ebnf.Alternation); ok {
+				g.coverage[&alt[0]] = make([]int, len(alt))
+			}
+		})
+	}
+	if g.initErr != nil {
+		return g.initErr
+	}
+
+	g.heights = map[string]int{}
+	for _, r := range g.Grammar.Rules {
+		g.heights[r.Name] = math.MaxInt32
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range g.Grammar.Rules {
+			if h := g.height(r.Expr) + 1; h < g.heights[r.Name] {
+				g.heights[r.Name], changed = h, true
+			}
+		}
+	}
+	if h, ok := g.heights[g.Start]; !ok {
+		g.initErr = fmt.Errorf("undefined start rule %s", g.Start)
+	} else if h >= math.MaxInt32 {
+		g.initErr = fmt.Errorf("start rule %s can't match any input", g.Start)
+	}
+	return g.initErr
+}
+
+// height returns the minimum number of nested rules needed to generate e.
+func (g *Generator) height(e ebnf.Expr) int {
+	switch e := e.(type) {
+	case ebnf.NonTerminal:
+		if h, ok := g.heights[string(e)]; ok {
+			return h
+		}
+		return 1
+	case ebnf.Group:
+		return g.height(e.Expr)
+	case ebnf.Alternation:
+		h := math.MaxInt32
+		for _, alt := range e {
+			if ah := g.height(alt); ah < h {
+				h = ah
+			}
+		}
+		return h
+	case ebnf.Sequence:
+		h := 0
+		for _, item := range e {
+			if ih := g.height(item); ih > h {
+				h = ih
+			}
+		}
+		return h
+	}
+	// Terminal, Option, Repetition, Empty
+	return 0
+}
+
+// Generate returns a random sentence.
+func (g *Generator) Generate() ([]rd.Token, error) {
+	if err := g.init(); err != nil {
+		return nil, err
+	}
+	var tokens []rd.Token
+	g.generate(ebnf.NonTerminal(g.Start), 0, &tokens)
+	return tokens, nil
+}
+
+func (g *Generator) generate(e ebnf.Expr, depth int, tokens *[]rd.Token) {
+	finish := depth >= g.MaxDepth || len(*tokens) >= g.MaxTokens
+	switch e := e.(type) {
+	case ebnf.Terminal:
+		*tokens = append(*tokens, g.terminal(string(e)))
+	case ebnf.NonTerminal:
+		if r := g.Grammar.Rule(string(e)); r != nil {
+			g.generate(r.Expr, depth+1, tokens)
+		} else {
+			*tokens = append(*tokens, g.Rules[string(e)](g.Rand)...)
+		}
+	case ebnf.Group:
+		g.generate(e.Expr, depth, tokens)
+	case ebnf.Option:
+		if !finish && g.productive(e.Expr) && g.Rand.Intn(2) == 0 {
+			g.generate(e.Expr, depth, tokens)
+		}
+	case ebnf.Repetition:
+		if !finish && g.productive(e.Expr) {
+			for n := g.Rand.Intn(g.MaxRepeat + 1); n > 0; n-- {
+				g.generate(e.Expr, depth, tokens)
+			}
+		}
+	case ebnf.Sequence:
+		for _, item := range e {
+			g.generate(item, depth, tokens)
+		}
+	case ebnf.Alternation:
+		g.generate(e[g.choose(e, finish)], depth, tokens)
+	}
+}
+
+// productive reports whether e can be generated. Options and repetitions always
+// can, being empty, but their contents might not, ex. ["b" B] with B = "b" B.
+func (g *Generator) productive(e ebnf.Expr) bool {
+	return g.height(e) < math.MaxInt32
+}
+
+// choose returns the index of the alternative to generate.
+func (g *Generator) choose(alts ebnf.Alternation, finish bool) int {
+	counts := g.coverage[&alts[0]]
+	minHeight := math.MaxInt32
+	if finish {
+		minHeight = g.height(alts)
+	}
+	var candidates []int
+	for i, alt := range alts {
+		if h := g.height(alt); h > minHeight || h >= math.MaxInt32 {
+			continue
+		}
+		switch {
+		case len(candidates) == 0 || counts[i] < counts[candidates[0]]:
+			candidates = append(candidates[:0], i)
+		case counts[i] == counts[candidates[0]]:
+			candidates = append(candidates, i)
+		}
+	}
+	i := candidates[g.Rand.Intn(len(candidates))]
+	counts[i]++
+	return i
+}
+
+func (g *Generator) terminal(terminal string) rd.Token {
+	if g.Terminal == nil {
+		return terminal
+	}
+	return g.Terminal(terminal)
+}
+
+// Coverage returns the number of alternatives chosen at least once so far, out of
+// all alternatives in the grammar.
+func (g *Generator) Coverage() (chosen, total int) {
+	for _, counts := range g.coverage {
+		for _, n := range counts {
+			if n > 0 {
+				chosen++
+			}
+		}
+		total += len(counts)
+	}
+	return chosen, total
+}
+
+// Mutate returns a copy of tokens with a random mutation: a token is deleted,
+// duplicated, swapped with the next one, or replaced by or preceded by a
+// terminal of the grammar. The result likely doesn't match the grammar, but it
+// might.
+func (g *Generator) Mutate(tokens []rd.Token) []rd.Token {
+	if err := g.init(); err != nil {
+		panic(errors.New("sentence: " + err.Error()))
+	}
+	var terminals []string
+	for _, r := range g.Grammar.Rules {
+		ebnf.Walk(r.Expr, func(e ebnf.Expr) {
+			if t, ok := e.(ebnf.Terminal); ok {
+				terminals = append(terminals, string(t))
+			}
+		})
+	}
+
+	mutated := append([]rd.Token{}, tokens...)
+	if len(mutated) == 0 {
+		if len(terminals) == 0 {
+			return mutated
+		}
+		return append(mutated, g.terminal(terminals[g.Rand.Intn(len(terminals))]))
+	}
+	i := g.Rand.Intn(len(mutated))
+	switch op := g.Rand.Intn(5); {
+	case op == 0:
+		return append(mutated[:i], mutated[i+1:]...)
+	case op == 1:
+		return append(mutated[:i+1], mutated[i:]...)
+	case op == 2 && i+1 < len(mutated):
+		mutated[i], mutated[i+1] = mutated[i+1], mutated[i]
+		return mutated
+	case len(terminals) == 0:
+		return append(mutated[:i], mutated[i+1:]...)
+	}
+	t := g.terminal(terminals[g.Rand.Intn(len(terminals))])
+	if g.Rand.Intn(2) == 0 {
+		mutated[i] = t
+		return mutated
+	}
+	return append(mutated[:i], append([]rd.Token{t}, mutated[i:]...)...)
+}
@@ -0,0 +1,124 @@
+package sentence
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/shivamMg/rd"
+	"github.com/shivamMg/rd/ebnf"
+	"github.com/stretchr/testify/assert"
+)
+
+const arithmeticGrammar = `
+	Expr   = Term {("+" | "-") Term} .
+	Term   = Factor {("*" | "/") Factor} .
+	Factor = "(" Expr ")" | "-" Factor | Number .
+`
+
+var numberRegex = regexp.MustCompile(`^\d+$`)
+
+func number(b *rd.Builder) bool {
+	return b.MatchFunc(func(token rd.Token) bool {
+		return numberRegex.MatchString(fmt.Sprint(token))
+	}, "number")
+}
+
+func generateNumber(r *rand.Rand) []rd.Token {
+	return []rd.Token{fmt.Sprint(r.Intn(100))}
+}
+
+func TestGenerate(t *testing.T) {
+	grammar := ebnf.MustParse(arithmeticGrammar)
+	g := &Generator{
+		Grammar:   grammar,
+		Rules:     map[string]func(r *rand.Rand) []rd.Token{"Number": generateNumber},
+		MaxTokens: 20,
+	}
+	in := &ebnf.Interpreter{Grammar: grammar, Rules: map[string]func(b *rd.Builder) bool{"Number": number}}
+	for i := 0; i < 50; i++ {
+		tokens, err := g.Generate()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NotEmpty(t, tokens)
+		// a generated Factor can add up to 3 tokens once the limit is reached
+		assert.True(t, len(tokens) < 20+40, tokens)
+		_, _, err = in.Parse(tokens, "Expr")
+		assert.NoError(t, err, "%v", tokens)
+	}
+	chosen, total := g.Coverage()
+	assert.Equal(t, 7, total)
+	assert.Equal(t, total, chosen)
+}
+
+func TestGenerate_Finish(t *testing.T) {
+	g := &Generator{
+		Grammar:  ebnf.MustParse(`A = "(" A ")" | B . B = ["b"] .`),
+		MaxDepth: 3,
+	}
+	for i := 0; i < 10; i++ {
+		tokens, err := g.Generate()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, len(tokens) <= 2*2+1, tokens)
+	}
+
+	// the option and the repetition can't be generated, but A can
+	g = &Generator{Grammar: ebnf.MustParse(`A = "a" [B] {"c" B} . B = "b" B | "c" B .`)}
+	for i := 0; i < 10; i++ {
+		tokens, err := g.Generate()
+		assert.NoError(t, err)
+		assert.Equal(t, []rd.Token{"a"}, tokens)
+	}
+
+	g = &Generator{Grammar: ebnf.MustParse(`A = "a" A .`)}
+	_, err := g.Generate()
+	assert.EqualError(t, err, "start rule A can't match any input")
+	g = &Generator{Grammar: ebnf.MustParse(`A = "a" B .`)}
+	_, err = g.Generate()
+	assert.EqualError(t, err, "rule A references undefined rule B")
+}
+
+func TestMutate(t *testing.T) {
+	g := &Generator{Grammar: ebnf.MustParse(`A = "a" "b" "c" .`)}
+	tokens := []rd.Token{"a", "b", "c"}
+	changed := 0
+	for i := 0; i < 20; i++ {
+		mutated := g.Mutate(tokens)
+		assert.InDelta(t, len(tokens), len(mutated), 1)
+		for _, token := range mutated {
+			assert.Contains(t, tokens, token)
+		}
+		if fmt.Sprint(mutated) != fmt.Sprint(tokens) {
+			changed++
+		}
+	}
+	assert.Equal(t, []rd.Token{"a", "b", "c"}, tokens)
+	assert.True(t, changed > 10, changed)
+}
+
+func FuzzInterpreter(f *testing.F) {
+	grammar := ebnf.MustParse(arithmeticGrammar)
+	in := &ebnf.Interpreter{Grammar: grammar, Rules: map[string]func(b *rd.Builder) bool{"Number": number}}
+	for seed := int64(0); seed < 10; seed++ {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		g := &Generator{
+			Grammar: grammar,
+			Rules:   map[string]func(r *rand.Rand) []rd.Token{"Number": generateNumber},
+			Rand:    rand.New(rand.NewSource(seed)),
+		}
+		tokens, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := in.Parse(tokens, "Expr"); err != nil {
+			t.Errorf("valid sentence %v not parsed: %v", tokens, err)
+		}
+		in.Parse(g.Mutate(tokens), "Expr")
+	})
+}
@@ -0,0 +1,180 @@
+package rd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ParsingError is error returned by Builder's Err method in case an error occurs
+// during parsing. It describes the furthest position the parser reached.
+type ParsingError struct {
+	// Index is the index of the furthest token reached.
+	Index int
+	// Token is the token found at Index. It's nil if no tokens were left.
+	Token Token
+	// Expected contains the tokens that failed Matches wanted at Index. Matches
+	// for a class of tokens (see Builder's MatchFunc) contribute a Label instead.
+	Expected []Token
+	// NonTerms contains the non-terminals that were active when Index was first
+	// reached, outermost first.
+	NonTerms []interface{}
+}
+
+func (e *ParsingError) Error() string {
+	found := "end of input"
+	if e.Token != nil {
+		found = fmt.Sprintf("`%v`", e.Token)
+	}
+	msg := fmt.Sprintf("unexpected %s at token %d", found, e.Index)
+	if len(e.Expected) > 0 {
+		msg = fmt.Sprintf("expected %s, found %s at token %d", sprintExpected(e.Expected), found, e.Index)
+	}
+	if len(e.NonTerms) > 0 {
+		msg += fmt.Sprint(" in ", e.NonTerms[len(e.NonTerms)-1])
+	}
+	return msg
+}
+
+// sprintExpected joins tokens as "`a`", "`a` or `b`", "`a`, `b` or `c`" etc.
+// Labels aren't quoted.
+func sprintExpected(tokens []Token) string {
+	quoted := make([]string, len(tokens))
+	for i, token := range tokens {
+		if label, ok := token.(Label); ok {
+			quoted[i] = label.String()
+		} else {
+			quoted[i] = fmt.Sprintf("`%v`", token)
+		}
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+// ErrorSymbol is the symbol of parse tree nodes inserted in place of the tokens
+// skipped during error recovery (see Builder's Sync method). The skipped tokens
+// are added as its subtrees.
+type ErrorSymbol struct {
+	Err *ParsingError
+}
+
+func (s ErrorSymbol) String() string {
+	return "<error>"
+}
+
+// NotConsumedError is error returned by Builder's Err method in case the root
+// non-terminal exits with a true result, but not all tokens have been consumed.
+type NotConsumedError struct {
+	// Index is the index of the first unconsumed token.
+	Index int
+	// Leftover contains the unconsumed tokens.
+	Leftover []Token
+	// NonTerm is the non-terminal that consumed the last consumed token. It's nil
+	// if no tokens were consumed.
+	NonTerm interface{}
+}
+
+func (e *NotConsumedError) Error() string {
+	if e.NonTerm == nil {
+		return fmt.Sprintf("unexpected `%v` at token %d", e.Leftover[0], e.Index)
+	}
+	return fmt.Sprintf("unexpected `%v` after %v at token %d", e.Leftover[0], e.NonTerm, e.Index)
+}
+
+// lastConsumer returns the symbol of the non-terminal holding the rightmost
+// terminal in t.
+func lastConsumer[T any](t *TreeOf[T]) interface{} {
+	var nonTerm interface{}
+	for len(t.Subtrees) > 0 {
+		nonTerm = t.Symbol
+		t = t.Subtrees[len(t.Subtrees)-1]
+	}
+	return nonTerm
+}
+
+// ErrorList is a list of parsing errors, returned by Builder's Errs method. It's
+// similar to go/scanner's ErrorList. The zero value is an empty list ready to use.
+type ErrorList []error
+
+// Add adds err to the list.
+func (l *ErrorList) Add(err error) {
+	*l = append(*l, err)
+}
+
+func (l ErrorList) Len() int {
+	return len(l)
+}
+
+func (l ErrorList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+// Less orders errors by their token index. Errors other than *ParsingError and
+// *NotConsumedError are ordered last.
+func (l ErrorList) Less(i, j int) bool {
+	return errorIndex(l[i]) < errorIndex(l[j])
+}
+
+// Sort sorts the list by token index. Errors at the same index keep their order.
+func (l ErrorList) Sort() {
+	sort.Stable(l)
+}
+
+// RemoveMultiples sorts the list and removes all but the first error per token
+// index.
+func (l *ErrorList) RemoveMultiples() {
+	l.Sort()
+	var deduped ErrorList
+	for i, err := range *l {
+		if i > 0 && errorIndex(err) != math.MaxInt && errorIndex(err) == errorIndex((*l)[i-1]) {
+			continue
+		}
+		deduped = append(deduped, err)
+	}
+	*l = deduped
+}
+
+// Truncate caps the list to its first n errors.
+func (l *ErrorList) Truncate(n int) {
+	if n < len(*l) {
+		*l = (*l)[:n]
+	}
+}
+
+// Error returns the first error's message, followed by a count of the rest.
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Err returns an error equivalent to this list. Returns nil if the list is empty.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// Unwrap returns the errors in the list. It lets errors.Is and errors.As inspect
+// each of them.
+func (l ErrorList) Unwrap() []error {
+	return l
+}
+
+func errorIndex(err error) int {
+	switch e := err.(type) {
+	case *ParsingError:
+		return e.Index
+	case *NotConsumedError:
+		return e.Index
+	}
+	return math.MaxInt
+}
@@ -16,46 +16,55 @@ const Grammar = `
 
 var numberRegex = regexp.MustCompile(`^(\d*\.\d+|\d+)$`)
 
+// Expr, Term and Factor are memoized: alternatives start by parsing the same
+// non-terminal at the same index, so it's only parsed once.
+
 func Expr(b *rd.Builder) (ok bool) {
 	defer b.Enter("Expr").Exit(&ok)
 
-	if Term(b) && b.Match(Plus) && Expr(b) {
-		return true
-	}
-	b.Backtrack()
-	if Term(b) && b.Match(Minus) && Expr(b) {
-		return true
-	}
-	b.Backtrack()
-	return Term(b)
+	return b.Memo(func() bool {
+		if Term(b) && b.Match(Plus) && Expr(b) {
+			return true
+		}
+		b.Backtrack()
+		if Term(b) && b.Match(Minus) && Expr(b) {
+			return true
+		}
+		b.Backtrack()
+		return Term(b)
+	})
 }
 
 func Term(b *rd.Builder) (ok bool) {
 	defer b.Enter("Term").Exit(&ok)
 
-	if Factor(b) && b.Match(Star) && Term(b) {
-		return true
-	}
-	b.Backtrack()
-	if Factor(b) && b.Match(Slash) && Term(b) {
-		return true
-	}
-	b.Backtrack()
-	return Factor(b)
+	return b.Memo(func() bool {
+		if Factor(b) && b.Match(Star) && Term(b) {
+			return true
+		}
+		b.Backtrack()
+		if Factor(b) && b.Match(Slash) && Term(b) {
+			return true
+		}
+		b.Backtrack()
+		return Factor(b)
+	})
 }
 
 func Factor(b *rd.Builder) (ok bool) {
 	defer b.Enter("Factor").Exit(&ok)
 
-	if b.Match(OpenParen) && Expr(b) && b.Match(CloseParen) {
-		return true
-	}
-	b.Backtrack()
-	if b.Match(Minus) && Factor(b) {
-		return true
-	}
-	b.Backtrack()
-	return Number(b)
+	return b.Memo(func() bool {
+		if b.Match(OpenParen) && Expr(b) && b.Match(CloseParen) {
+			return true
+		}
+		b.Backtrack()
+		if b.Match(Minus) && Factor(b) {
+			return true
+		}
+		b.Backtrack()
+		return Number(b)
+	})
 }
 
 func Number(b *rd.Builder) (ok bool) {
@@ -74,7 +83,7 @@ func Number(b *rd.Builder) (ok bool) {
 }
 
 func Parse(tokens []rd.Token) (parseTree *rd.Tree, debugTree *rd.DebugTree, err error) {
-	b := rd.NewBuilder(tokens)
+	b := rd.NewBuilder(tokens, rd.Memoize())
 	if ok := Expr(b); ok && b.Err() == nil {
 		return b.ParseTree(), b.DebugTree(), nil
 	}
@@ -0,0 +1,33 @@
+// Package generatedparser parses the backtracking parser's grammar, with a parser
+// generated by rdgen from grammar.ebnf. Only Number is hand-written.
+package generatedparser
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+
+	"github.com/shivamMg/rd"
+)
+
+//go:generate rdgen -tokens tokens.txt -memo -o parser.go grammar.ebnf
+
+//go:embed grammar.ebnf
+var Grammar string
+
+var numberRegex = regexp.MustCompile(`^(\d*\.\d+|\d+)$`)
+
+func Number(b *rd.Builder) (ok bool) {
+	defer b.Enter("Number").Exit(&ok)
+
+	token, ok := b.Next()
+	if !ok {
+		return false
+	}
+	if numberRegex.MatchString(fmt.Sprint(token)) {
+		b.Add(token)
+		return true
+	}
+	b.Backtrack()
+	return false
+}
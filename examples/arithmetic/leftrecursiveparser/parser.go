@@ -0,0 +1,135 @@
+package leftrecursiveparser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/shivamMg/rd"
+	. "github.com/shivamMg/rd/examples/arithmetic/tokens"
+)
+
+const Grammar = `
+	Expr   = Expr "+" Term | Expr "-" Term | Term
+	Term   = Term "*" Factor | Term "/" Factor | Factor
+	Factor = "(" Expr ")" | "-" Factor | Number
+`
+
+var numberRegex = regexp.MustCompile(`^(\d*\.\d+|\d+)$`)
+
+// Expr and Term are left-recursive. They're memoized, which lets the builder grow
+// their results from left to right, and makes the operators left-associative.
+
+func Expr(b *rd.Builder) (ok bool) {
+	defer b.Enter("Expr").Exit(&ok)
+
+	return b.Memo(func() bool {
+		if Expr(b) && b.Match(Plus) && Term(b) {
+			return true
+		}
+		b.Backtrack()
+		if Expr(b) && b.Match(Minus) && Term(b) {
+			return true
+		}
+		b.Backtrack()
+		return Term(b)
+	})
+}
+
+func Term(b *rd.Builder) (ok bool) {
+	defer b.Enter("Term").Exit(&ok)
+
+	return b.Memo(func() bool {
+		if Term(b) && b.Match(Star) && Factor(b) {
+			return true
+		}
+		b.Backtrack()
+		if Term(b) && b.Match(Slash) && Factor(b) {
+			return true
+		}
+		b.Backtrack()
+		return Factor(b)
+	})
+}
+
+func Factor(b *rd.Builder) (ok bool) {
+	defer b.Enter("Factor").Exit(&ok)
+
+	if b.Match(OpenParen) && Expr(b) && b.Match(CloseParen) {
+		return true
+	}
+	b.Backtrack()
+	if b.Match(Minus) && Factor(b) {
+		return true
+	}
+	b.Backtrack()
+	return Number(b)
+}
+
+func Number(b *rd.Builder) (ok bool) {
+	defer b.Enter("Number").Exit(&ok)
+
+	token, ok := b.Next()
+	if !ok {
+		return false
+	}
+	if numberRegex.MatchString(fmt.Sprint(token)) {
+		b.Add(token)
+		return true
+	}
+	b.Backtrack()
+	return false
+}
+
+func Parse(tokens []rd.Token) (parseTree *rd.Tree, debugTree *rd.DebugTree, err error) {
+	b := rd.NewBuilder(tokens, rd.Memoize())
+	if ok := Expr(b); ok && b.Err() == nil {
+		return b.ParseTree(), b.DebugTree(), nil
+	}
+	return nil, b.DebugTree(), b.Err()
+}
+
+// Actions evaluate expressions while parsing them.
+var Actions = map[interface{}]rd.Action{
+	"Expr": rd.TypedAction(binaryOp),
+	"Term": rd.TypedAction(binaryOp),
+	"Factor": rd.TypedAction(func(values []interface{}) float64 {
+		switch len(values) {
+		case 3: // "(" Expr ")"
+			return values[1].(float64)
+		case 2: // "-" Factor
+			return -values[1].(float64)
+		}
+		return values[0].(float64)
+	}),
+	"Number": rd.TypedAction(func(values []interface{}) float64 {
+		f, _ := strconv.ParseFloat(fmt.Sprint(values[0]), 64)
+		return f
+	}),
+}
+
+func binaryOp(values []interface{}) float64 {
+	if len(values) == 1 {
+		return values[0].(float64)
+	}
+	x, y := values[0].(float64), values[2].(float64)
+	switch values[1] {
+	case Plus:
+		return x + y
+	case Minus:
+		return x - y
+	case Star:
+		return x * y
+	}
+	return x / y
+}
+
+// Evaluate parses tokens and returns the value of the expression.
+func Evaluate(tokens []rd.Token) (float64, error) {
+	b := rd.NewBuilder(tokens, rd.Memoize(), rd.Actions(Actions), rd.NoDebugTree())
+	if ok := Expr(b); ok && b.Err() == nil {
+		value, _ := rd.ValueOf[float64](b.ParseTree())
+		return value, nil
+	}
+	return 0, b.Err()
+}
@@ -9,12 +9,18 @@ import (
 
 	"github.com/shivamMg/rd"
 	"github.com/shivamMg/rd/examples/arithmetic/backtrackingparser"
+	"github.com/shivamMg/rd/examples/arithmetic/generatedparser"
+	"github.com/shivamMg/rd/examples/arithmetic/leftrecursiveparser"
 	"github.com/shivamMg/rd/examples/arithmetic/parser"
+	"github.com/shivamMg/rd/examples/arithmetic/prattparser"
 )
 
 var (
-	useBacktrackingParser = flag.Bool("backtrackingparser", false, "use backtracking parser")
-	expr                  = flag.String("expr", "", "arithmetic expression to be parsed")
+	useBacktrackingParser  = flag.Bool("backtrackingparser", false, "use backtracking parser")
+	useLeftRecursiveParser = flag.Bool("leftrecursiveparser", false, "use left-recursive parser")
+	useGeneratedParser     = flag.Bool("generatedparser", false, "use parser generated by rdgen")
+	usePrattParser         = flag.Bool("prattparser", false, "use parser based on an operator table")
+	expr                   = flag.String("expr", "", "arithmetic expression to be parsed")
 )
 
 func main() {
@@ -29,14 +35,23 @@ func main() {
 	}
 	printTokens(tokens)
 
-	fmt.Print("Grammar:")
-	fmt.Print(parser.Grammar)
-
 	var parseTree *rd.Tree
 	var debugTree *rd.DebugTree
-	if *useBacktrackingParser {
+	switch {
+	case *useBacktrackingParser:
+		fmt.Print("Grammar:", backtrackingparser.Grammar)
 		parseTree, debugTree, err = backtrackingparser.Parse(tokens)
-	} else {
+	case *useLeftRecursiveParser:
+		fmt.Print("Grammar:", leftrecursiveparser.Grammar)
+		parseTree, debugTree, err = leftrecursiveparser.Parse(tokens)
+	case *useGeneratedParser:
+		fmt.Print("Grammar:\n", generatedparser.Grammar)
+		parseTree, debugTree, err = generatedparser.Parse(tokens)
+	case *usePrattParser:
+		fmt.Print("Grammar:", prattparser.Grammar)
+		parseTree, debugTree, err = prattparser.Parse(tokens)
+	default:
+		fmt.Print("Grammar:", parser.Grammar)
 		parseTree, debugTree, err = parser.Parse(tokens)
 	}
 	if err != nil {
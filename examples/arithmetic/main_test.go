@@ -1,10 +1,15 @@
 package main
 
 import (
+	"math"
 	"testing"
 
 	"github.com/shivamMg/rd"
+	"github.com/shivamMg/rd/examples/arithmetic/backtrackingparser"
+	"github.com/shivamMg/rd/examples/arithmetic/generatedparser"
+	"github.com/shivamMg/rd/examples/arithmetic/leftrecursiveparser"
 	"github.com/shivamMg/rd/examples/arithmetic/parser"
+	"github.com/shivamMg/rd/examples/arithmetic/prattparser"
 )
 
 func TestArithmeticExpressionsGrammar(t *testing.T) {
@@ -110,3 +115,82 @@ func TestArithmeticExpressionsGrammar(t *testing.T) {
 		t.Errorf("invalid parse tree. want: %s\ngot: %s\n", expectedParseTree, got)
 	}
 }
+
+func TestGeneratedParser(t *testing.T) {
+	tokens := []rd.Token{"2.8", "+", "(", "3", "-", ".733", ")", "/", "23"}
+	parseTree, debugTree, err := generatedparser.Parse(tokens)
+	if err != nil {
+		t.Fatal("parsing failed:", err)
+	}
+	// generated from the backtracking parser's grammar, it must parse the same way
+	expectedParseTree, expectedDebugTree, _ := backtrackingparser.Parse(tokens)
+	if got := debugTree.String(); got != expectedDebugTree.String() {
+		t.Errorf("invalid debug tree. expected: %s\ngot: %s\n", expectedDebugTree, got)
+	}
+	if got := parseTree.String(); got != expectedParseTree.String() {
+		t.Errorf("invalid parse tree. expected: %s\ngot: %s\n", expectedParseTree, got)
+	}
+}
+
+func TestPrattParser(t *testing.T) {
+	tokens := []rd.Token{"2.8", "+", "(", "3", "-", ".733", ")", "/", "23", "-", "1"}
+	parseTree, _, err := prattparser.Parse(tokens)
+	if err != nil {
+		t.Fatal("parsing failed:", err)
+	}
+	expectedParseTree := `Expr
+└─ Infix
+   ├─ Infix
+   │  ├─ Factor
+   │  │  └─ Number
+   │  │     └─ 2.8
+   │  ├─ +
+   │  └─ Infix
+   │     ├─ Factor
+   │     │  ├─ (
+   │     │  ├─ Expr
+   │     │  │  └─ Infix
+   │     │  │     ├─ Factor
+   │     │  │     │  └─ Number
+   │     │  │     │     └─ 3
+   │     │  │     ├─ -
+   │     │  │     └─ Factor
+   │     │  │        └─ Number
+   │     │  │           └─ .733
+   │     │  └─ )
+   │     ├─ /
+   │     └─ Factor
+   │        └─ Number
+   │           └─ 23
+   ├─ -
+   └─ Factor
+      └─ Number
+         └─ 1
+`
+	if got := parseTree.String(); got != expectedParseTree {
+		t.Errorf("invalid parse tree. expected: %s\ngot: %s\n", expectedParseTree, got)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		tokens   []rd.Token
+		expected float64
+	}{
+		{[]rd.Token{"2.8", "+", "(", "3", "-", ".733", ")", "/", "23"}, 2.8 + (3-.733)/23},
+		{[]rd.Token{"8", "-", "2", "-", "1"}, 5},
+		{[]rd.Token{"-", "2", "*", "3", "/", "4"}, -1.5},
+	}
+	for _, test := range tests {
+		got, err := leftrecursiveparser.Evaluate(test.tokens)
+		if err != nil {
+			t.Fatal("evaluation failed:", err)
+		}
+		if math.Abs(got-test.expected) > 1e-9 {
+			t.Errorf("invalid value for %v. expected: %v. got: %v.", test.tokens, test.expected, got)
+		}
+	}
+	if _, err := leftrecursiveparser.Evaluate([]rd.Token{"1", "+"}); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,62 @@
+package prattparser
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/shivamMg/rd"
+	. "github.com/shivamMg/rd/examples/arithmetic/tokens"
+)
+
+const Grammar = `
+	Expr   = Expr ("+" | "-") Expr | Expr ("*" | "/") Expr | "-" Expr | Factor
+	Factor = "(" Expr ")" | Number
+`
+
+var numberRegex = regexp.MustCompile(`^(\d*\.\d+|\d+)$`)
+
+// Operators replaces the Expr/Term layering of the other parsers. The builder
+// nests operators according to their precedence, so the grammar doesn't need a
+// rule per precedence level. From the loosest to the tightest: infix "+" and "-",
+// infix "*" and "/", prefix "-". Infix operators are left-associative.
+var Operators = rd.NewOperatorTable[rd.Token]().
+	Infix(1, rd.LeftAssoc, Plus, Minus).
+	Infix(2, rd.LeftAssoc, Star, Slash).
+	Prefix(3, Minus)
+
+func Expr(b *rd.Builder) (ok bool) {
+	defer b.Enter("Expr").Exit(&ok)
+
+	return b.Operators(Operators, Factor)
+}
+
+func Factor(b *rd.Builder) (ok bool) {
+	defer b.Enter("Factor").Exit(&ok)
+
+	if b.Match(OpenParen) {
+		return Expr(b) && b.Match(CloseParen)
+	}
+	return Number(b)
+}
+
+func Number(b *rd.Builder) (ok bool) {
+	defer b.Enter("Number").Exit(&ok)
+
+	token, ok := b.Next()
+	if !ok {
+		return false
+	}
+	if numberRegex.MatchString(fmt.Sprint(token)) {
+		b.Add(token)
+		return true
+	}
+	return false
+}
+
+func Parse(tokens []rd.Token) (parseTree *rd.Tree, debugTree *rd.DebugTree, err error) {
+	b := rd.NewBuilder(tokens)
+	if ok := Expr(b); ok && b.Err() == nil {
+		return b.ParseTree(), b.DebugTree(), nil
+	}
+	return nil, b.DebugTree(), b.Err()
+}
@@ -1,5 +1,7 @@
 module github.com/shivamMg/rd/examples/pl0
 
+go 1.20
+
 require (
 	github.com/alecthomas/chroma v0.6.0
 	github.com/dlclark/regexp2 v1.1.6 // indirect
@@ -30,6 +30,9 @@ func main() {
 	parseTree, debugTree, err := parser.Parse(tokens)
 	if err != nil {
 		fmt.Print("Debug Tree:\n\n", debugTree)
+		if parseTree != nil {
+			fmt.Print("\nParse Tree:\n\n", parseTree)
+		}
 		printExit("parsing failed.", err)
 	}
 
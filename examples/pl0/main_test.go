@@ -1,10 +1,19 @@
 package main_test
 
 import (
+	"bytes"
+	"fmt"
+	"math/rand"
 	"testing"
 
+	"github.com/shivamMg/rd"
+	"github.com/shivamMg/rd/ebnf"
+	"github.com/shivamMg/rd/ebnf/analysis"
+	"github.com/shivamMg/rd/ebnf/sentence"
 	"github.com/shivamMg/rd/examples/pl0/lexer"
 	"github.com/shivamMg/rd/examples/pl0/parser"
+	"github.com/shivamMg/rd/examples/pl0/tokens"
+	"github.com/shivamMg/rd/query"
 )
 
 const (
@@ -900,3 +909,175 @@ func TestPrograms(t *testing.T) {
 		}
 	}
 }
+
+func TestProgramWithErrors(t *testing.T) {
+	program := `VAR x;
+BEGIN
+   x := ;
+   ! x
+END.`
+	tokens, err := lexer.Lex(program)
+	if err != nil {
+		t.Error("lexing failed.", err)
+	}
+	parseTree, _, err := parser.Parse(tokens)
+	if err == nil || err.Error() != "expected `+`, `-` or `(`, found `;` at token 6 in Expression" {
+		t.Errorf("invalid error. got: %v", err)
+	}
+	if parseTree == nil {
+		t.Fatal("parse tree must be set after recovery")
+	}
+	if got := parseTree.Subtrees[0].Subtrees[3].Subtrees[1].String(); got != "Statement\n└─ <error>\n   ├─ x\n   └─ :=\n" {
+		t.Errorf("invalid recovered statement. got: %s", got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var symbols []interface{}
+	for _, token := range tokens.All() {
+		symbols = append(symbols, token)
+	}
+	codec := rd.NewSymbolTable(symbols...)
+
+	toks, err := lexer.Lex(squareProgram)
+	if err != nil {
+		t.Fatal("lexing failed.", err)
+	}
+	parseTree, debugTree, err := parser.Parse(toks)
+	if err != nil {
+		t.Fatal("parsing failed.", err)
+	}
+
+	var buf bytes.Buffer
+	if err := rd.EncodeTree(&buf, parseTree, codec); err != nil {
+		t.Fatal(err)
+	}
+	gotParseTree, err := rd.DecodeTree[rd.Token](&buf, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gotParseTree.String(); got != squareProgramParseTree {
+		t.Errorf("invalid decoded parse tree. expected: %s. got: %s.", squareProgramParseTree, got)
+	}
+	if _, ok := gotParseTree.Subtrees[1].Symbol.(tokens.Token); !ok {
+		t.Errorf("token decoded as %T", gotParseTree.Subtrees[1].Symbol)
+	}
+
+	buf.Reset()
+	if err := rd.EncodeDebugTree(&buf, debugTree, codec); err != nil {
+		t.Fatal(err)
+	}
+	gotDebugTree, err := rd.DecodeDebugTree(&buf, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gotDebugTree.String(); got != squareProgramDebugTree {
+		t.Errorf("invalid decoded debug tree. expected: %s. got: %s.", squareProgramDebugTree, got)
+	}
+}
+
+// declaredIdents returns the identifiers declared in block: its constants,
+// variables and procedures.
+func declaredIdents(block *rd.Tree) (idents []string) {
+	declaring := false
+	c := block.Cursor()
+	for ok := c.GotoFirstChild(); ok; ok = c.GotoNextSibling() {
+		switch c.Node().Symbol {
+		case tokens.Const, tokens.Var, tokens.Procedure:
+			declaring = true
+		case tokens.Semicolon:
+			declaring = false
+		case "Ident":
+			if declaring {
+				idents = append(idents, fmt.Sprint(c.Node().Subtrees[0].Symbol))
+			}
+		}
+	}
+	return idents
+}
+
+func TestDeclaredIdents(t *testing.T) {
+	toks, err := lexer.Lex(squareProgram)
+	if err != nil {
+		t.Fatal("lexing failed.", err)
+	}
+	parseTree, _, err := parser.Parse(toks)
+	if err != nil {
+		t.Fatal("parsing failed.", err)
+	}
+
+	var got []string
+	for _, block := range parseTree.FindAllSymbol("Block") {
+		got = append(got, fmt.Sprint(declaredIdents(block)))
+	}
+	expected := []string{"[x squ square]", "[]"}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("invalid declared idents. expected: %v. got: %v.", expected, got)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	toks, err := lexer.Lex(squareProgram)
+	if err != nil {
+		t.Fatal("lexing failed.", err)
+	}
+	parseTree, _, err := parser.Parse(toks)
+	if err != nil {
+		t.Fatal("parsing failed.", err)
+	}
+
+	tests := []struct {
+		selector *query.Selector
+		expected string
+	}{
+		{query.MustCompile("Statement:has(> $1:first-child) > Ident", tokens.Call), "[square]"},
+		{query.MustCompile("Block > var + Ident, Block > var ~ ',' + Ident"), "[x squ]"},
+		{query.MustCompile("Statement > Ident:first-child"), "[squ x x]"},
+	}
+	for _, test := range tests {
+		var got []rd.Token
+		for _, node := range test.selector.Select(parseTree) {
+			got = append(got, node.Tokens()...)
+		}
+		if fmt.Sprint(got) != test.expected {
+			t.Errorf("invalid tokens selected by %s. expected: %s. got: %v.", test.selector, test.expected, got)
+		}
+	}
+}
+
+func TestGrammar(t *testing.T) {
+	// the parser uses one-token lookahead, which requires an LL(1) grammar
+	if err := analysis.Analyze(ebnf.MustParse(parser.Grammar), "").Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	grammar := ebnf.MustParse(parser.Grammar)
+	for seed := int64(0); seed < 10; seed++ {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		g := &sentence.Generator{
+			Grammar: grammar,
+			Terminal: func(terminal string) rd.Token {
+				token, _ := tokens.TokenFromString(terminal)
+				return token
+			},
+			Rules: map[string]func(r *rand.Rand) []rd.Token{
+				"ident":  func(r *rand.Rand) []rd.Token { return []rd.Token{fmt.Sprint("x", r.Intn(10))} },
+				"number": func(r *rand.Rand) []rd.Token { return []rd.Token{fmt.Sprint(r.Intn(100))} },
+			},
+			Rand: rand.New(rand.NewSource(seed)),
+		}
+		program, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := parser.Parse(program); err != nil {
+			t.Errorf("valid program %v not parsed: %v", program, err)
+		}
+		// invalid programs mustn't panic
+		parser.Parse(g.Mutate(program))
+	})
+}
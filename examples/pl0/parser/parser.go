@@ -40,12 +40,12 @@ const Grammar = `
 		| "(" expression ")" .
 `
 
+// Parse parses tokens as a PL/0 program. Statements recover from syntax errors,
+// so parseTree can be non-nil even if err isn't.
 func Parse(tokens []rd.Token) (parseTree *rd.Tree, debugTree *rd.DebugTree, err error) {
 	b := rd.NewBuilder(tokens)
-	if ok := Program(b); !ok {
-		return nil, b.DebugTree(), b.Err()
-	}
-	return b.ParseTree(), b.DebugTree(), nil
+	Program(b)
+	return b.ParseTree(), b.DebugTree(), b.Err()
 }
 
 func Program(b *rd.Builder) (ok bool) {
@@ -93,7 +93,7 @@ procedureCheck:
 }
 
 func Statement(b *rd.Builder) (ok bool) {
-	b.Enter("Statement")
+	b.Enter("Statement").Sync(Semicolon, End, Period)
 	defer b.Exit(&ok)
 
 	switch {
@@ -51,3 +51,8 @@ func TokenFromString(s string) (Token, bool) {
 	token, ok := m[s]
 	return token, ok
 }
+
+// All returns all tokens.
+func All() []Token {
+	return append([]Token(nil), all...)
+}
@@ -0,0 +1,248 @@
+package rd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shivamMg/ppds/tree"
+)
+
+// GraphOption configures the graphs written by the WriteDOT and WriteMermaid
+// methods of Tree and DebugTree.
+type GraphOption func(*graphOptions)
+
+type graphOptions struct {
+	collapseDepth int // 0 if nothing is collapsed
+}
+
+// CollapseBelow collapses subtrees of nodes at depth (the root is at depth 0).
+// Collapsed nodes are drawn with dashes, and the number of nodes hidden below
+// them. It helps keep graphs of large trees readable.
+func CollapseBelow(depth int) GraphOption {
+	if depth < 0 {
+		panic("CollapseBelow depth must not be negative")
+	}
+	return func(o *graphOptions) {
+		o.collapseDepth = depth + 1
+	}
+}
+
+// nodeKind decides how a node is drawn.
+type nodeKind int
+
+const (
+	nonTermNode nodeKind = iota
+	termNode
+	// failedNonTermNode and failedTermNode are for non-terminals that exited
+	// with a false result and failed matches in debug trees
+	failedNonTermNode
+	failedTermNode
+	// noteNode is for debug tree nodes that aren't non-terminals or matches, ex.
+	// "<memo hit>"
+	noteNode
+)
+
+// graphNode is a node to be drawn. Children are nil for collapsed nodes.
+type graphNode struct {
+	label     string
+	kind      nodeKind
+	children  []*graphNode
+	collapsed int // number of nodes hidden below
+}
+
+func buildGraph(n tree.Node, kind func(tree.Node) nodeKind, opts []GraphOption) *graphNode {
+	var o graphOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var build func(n tree.Node, depth int) *graphNode
+	build = func(n tree.Node, depth int) *graphNode {
+		gn := &graphNode{label: fmt.Sprint(n.Data()), kind: kind(n)}
+		if o.collapseDepth > 0 && depth+1 == o.collapseDepth {
+			gn.collapsed = countNodes(n) - 1
+			return gn
+		}
+		for _, c := range n.Children() {
+			gn.children = append(gn.children, build(c, depth+1))
+		}
+		return gn
+	}
+	return build(n, 0)
+}
+
+func countNodes(n tree.Node) int {
+	count := 1
+	for _, c := range n.Children() {
+		count += countNodes(c)
+	}
+	return count
+}
+
+func treeNodeKind[T any](n tree.Node) nodeKind {
+	if t := n.(*TreeOf[T]); len(t.Subtrees) == 0 {
+		if _, ok := t.Token(); ok {
+			return termNode
+		}
+	}
+	return nonTermNode
+}
+
+func debugTreeNodeKind(n tree.Node) nodeKind {
+	dt, ok := n.(*DebugTree)
+	if !ok {
+		return noteNode
+	}
+	switch e := dt.Event.(type) {
+	case EnterEvent:
+		return nonTermNode
+	case ExitEvent:
+		if !e.Result {
+			return failedNonTermNode
+		}
+		return nonTermNode
+	case MatchEvent:
+		if !e.OK {
+			return failedTermNode
+		}
+		return termNode
+	}
+	return noteNode
+}
+
+// WriteDOT writes t as a Graphviz DOT graph. Non-terminals are drawn as boxes, and
+// terminals (tokens without subtrees) as ellipses.
+func (t *TreeOf[T]) WriteDOT(w io.Writer, opts ...GraphOption) error {
+	return writeDOT(w, buildGraph(t, treeNodeKind[T], opts))
+}
+
+// WriteMermaid writes t as a Mermaid flowchart. Non-terminals are drawn as boxes,
+// and terminals (tokens without subtrees) as stadiums.
+func (t *TreeOf[T]) WriteMermaid(w io.Writer, opts ...GraphOption) error {
+	return writeMermaid(w, buildGraph(t, treeNodeKind[T], opts))
+}
+
+// WriteDOT writes dt as a Graphviz DOT graph. Like Tree's WriteDOT, non-terminals
+// are boxes and matches are ellipses. Non-terminals that exited with a false
+// result and failed matches are red.
+func (dt *DebugTree) WriteDOT(w io.Writer, opts ...GraphOption) error {
+	return writeDOT(w, buildGraph(dt, debugTreeNodeKind, opts))
+}
+
+// WriteMermaid writes dt as a Mermaid flowchart. Like Tree's WriteMermaid,
+// non-terminals are boxes and matches are stadiums. Non-terminals that exited
+// with a false result and failed matches are red.
+func (dt *DebugTree) WriteMermaid(w io.Writer, opts ...GraphOption) error {
+	return writeMermaid(w, buildGraph(dt, debugTreeNodeKind, opts))
+}
+
+// walkGraph calls f for each node, depth-first, with the node's id and its parent's
+// id. Ids are n0, n1, ... in the order nodes are visited. The root's parent id is
+// "".
+func walkGraph(root *graphNode, f func(gn *graphNode, id, parentID string)) {
+	next := 0
+	var walk func(gn *graphNode, parentID string)
+	walk = func(gn *graphNode, parentID string) {
+		id := fmt.Sprint("n", next)
+		next++
+		f(gn, id, parentID)
+		for _, c := range gn.children {
+			walk(c, id)
+		}
+	}
+	walk(root, "")
+}
+
+func dotAttrs(gn *graphNode) string {
+	shape, styles, colors := "box", []string{"rounded"}, ""
+	switch gn.kind {
+	case termNode, failedTermNode:
+		shape, styles = "ellipse", nil
+	case noteNode:
+		shape, styles = "plaintext", nil
+	}
+	if gn.kind == failedNonTermNode || gn.kind == failedTermNode {
+		styles = append(styles, "filled")
+		colors = `, color="#cc0000", fillcolor="#ffdddd"`
+	}
+	if gn.collapsed > 0 {
+		styles = append(styles, "dashed")
+	}
+	attrs := "shape=" + shape
+	if len(styles) > 0 {
+		attrs += fmt.Sprintf(`, style="%s"`, strings.Join(styles, ","))
+	}
+	return attrs + colors
+}
+
+func writeDOT(w io.Writer, root *graphNode) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph {\n\tnode [fontname=\"Helvetica\"];\n")
+	walkGraph(root, func(gn *graphNode, id, parentID string) {
+		label := gn.label
+		if gn.collapsed > 0 {
+			label += fmt.Sprintf(" (+%d)", gn.collapsed)
+		}
+		fmt.Fprintf(bw, "\t%s [label=%s, %s];\n", id, dotQuote(label), dotAttrs(gn))
+		if parentID != "" {
+			fmt.Fprintf(bw, "\t%s -> %s;\n", parentID, id)
+		}
+	})
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+var mermaidShapes = map[nodeKind][2]string{
+	nonTermNode:       {`["`, `"]`},
+	termNode:          {`(["`, `"])`},
+	failedNonTermNode: {`["`, `"]`},
+	failedTermNode:    {`(["`, `"])`},
+	noteNode:          {`>"`, `"]`},
+}
+
+var mermaidClasses = map[nodeKind]string{
+	failedNonTermNode: "failed",
+	failedTermNode:    "failed",
+	noteNode:          "note",
+}
+
+func writeMermaid(w io.Writer, root *graphNode) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("flowchart TD\n")
+	classes := map[string][]string{}
+	walkGraph(root, func(gn *graphNode, id, parentID string) {
+		label := gn.label
+		if gn.collapsed > 0 {
+			label += fmt.Sprintf(" (+%d)", gn.collapsed)
+			classes["collapsed"] = append(classes["collapsed"], id)
+		}
+		if class, ok := mermaidClasses[gn.kind]; ok {
+			classes[class] = append(classes[class], id)
+		}
+		shape := mermaidShapes[gn.kind]
+		fmt.Fprintf(bw, "\t%s%s%s%s\n", id, shape[0], mermaidEscape(label), shape[1])
+		if parentID != "" {
+			fmt.Fprintf(bw, "\t%s --> %s\n", parentID, id)
+		}
+	})
+	bw.WriteString("\tclassDef failed fill:#ffdddd,stroke:#cc0000,color:#cc0000\n")
+	bw.WriteString("\tclassDef note fill:none,stroke:none\n")
+	bw.WriteString("\tclassDef collapsed stroke-dasharray:4 4\n")
+	for _, class := range []string{"failed", "note", "collapsed"} {
+		if ids := classes[class]; len(ids) > 0 {
+			fmt.Fprintf(bw, "\tclass %s %s\n", strings.Join(ids, ","), class)
+		}
+	}
+	return bw.Flush()
+}
+
+// mermaidEscape escapes characters that end labels or are read as HTML, using
+// Mermaid's entity codes.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`#`, "#35;", `"`, "#34;", `<`, "#60;", `>`, "#62;", `&`, "#38;", "\n", "<br>").Replace(s)
+}
@@ -0,0 +1,95 @@
+package rd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTree_WriteDOT(t *testing.T) {
+	tree := NewTree("Expr", NewTree("Term", NewTree("x")), NewTree("+"), NewTree("Term", NewTree(`"y"`)))
+
+	var buf bytes.Buffer
+	assert.Nil(t, tree.WriteDOT(&buf))
+	expected := `digraph {
+	node [fontname="Helvetica"];
+	n0 [label="Expr", shape=box, style="rounded"];
+	n1 [label="Term", shape=box, style="rounded"];
+	n0 -> n1;
+	n2 [label="x", shape=ellipse];
+	n1 -> n2;
+	n3 [label="+", shape=ellipse];
+	n0 -> n3;
+	n4 [label="Term", shape=box, style="rounded"];
+	n0 -> n4;
+	n5 [label="\"y\"", shape=ellipse];
+	n4 -> n5;
+}
+`
+	assert.Equal(t, expected, buf.String())
+
+	buf.Reset()
+	assert.Nil(t, tree.WriteMermaid(&buf, CollapseBelow(0)))
+	expected = `flowchart TD
+	n0["Expr (+5)"]
+	classDef failed fill:#ffdddd,stroke:#cc0000,color:#cc0000
+	classDef note fill:none,stroke:none
+	classDef collapsed stroke-dasharray:4 4
+	class n0 collapsed
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestDebugTree_WriteDOT(t *testing.T) {
+	b := NewBuilder([]Token{"x", "+", "(", "x", "+", "y", ")"})
+	assert.False(t, benchExpr(b))
+
+	var buf bytes.Buffer
+	assert.Nil(t, b.DebugTree().WriteDOT(&buf, CollapseBelow(2)))
+	expected := `digraph {
+	node [fontname="Helvetica"];
+	n0 [label="Expr(false)", shape=box, style="rounded,filled", color="#cc0000", fillcolor="#ffdddd"];
+	n1 [label="Term(true)", shape=box, style="rounded"];
+	n0 -> n1;
+	n2 [label="x ≠ (", shape=ellipse, style="filled", color="#cc0000", fillcolor="#ffdddd"];
+	n1 -> n2;
+	n3 [label="x = x", shape=ellipse];
+	n1 -> n3;
+	n4 [label="+ = +", shape=ellipse];
+	n0 -> n4;
+	n5 [label="Term(false)", shape=box, style="rounded,filled", color="#cc0000", fillcolor="#ffdddd"];
+	n0 -> n5;
+	n6 [label="( = (", shape=ellipse];
+	n5 -> n6;
+	n7 [label="Expr(false) (+7)", shape=box, style="rounded,filled,dashed", color="#cc0000", fillcolor="#ffdddd"];
+	n5 -> n7;
+}
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestDebugTree_WriteMermaid(t *testing.T) {
+	b := NewBuilder([]Token{"x"}, Memoize())
+	memoHitExpr(b, func() bool { return false })
+
+	var buf bytes.Buffer
+	assert.Nil(t, b.DebugTree().WriteMermaid(&buf))
+	expected := `flowchart TD
+	n0["Expr(false)"]
+	n1["Term(true)"]
+	n0 --> n1
+	n2(["x = #60;any#62;"])
+	n1 --> n2
+	n3["Term(true)"]
+	n0 --> n3
+	n4>"#60;memo hit#62;"]
+	n3 --> n4
+	classDef failed fill:#ffdddd,stroke:#cc0000,color:#cc0000
+	classDef note fill:none,stroke:none
+	classDef collapsed stroke-dasharray:4 4
+	class n0 failed
+	class n4 note
+`
+	assert.Equal(t, expected, buf.String())
+}
@@ -1,31 +1,64 @@
 package rd
 
-type ele struct {
+type ele[T comparable] struct {
 	index   int
-	nonTerm *Tree
+	nonTerm *TreeOf[T]
+	sync    []T
+	errs    int           // number of errors recovered from before entering
+	seed    *memoEntry[T] // in-progress memo entry, set if body is called by Memo
+	noMemo  bool          // result depends on a left-recursive seed, so it mustn't be memoized
+	hit     *memoEntry[T] // memoized result to be replayed on exit
 }
 
-type stack []ele
+type memoKey struct {
+	nonTerm interface{}
+	index   int
+}
+
+type memoEntry[T comparable] struct {
+	result     bool
+	skip       bool
+	end        int
+	tree       *TreeOf[T]
+	errs       ErrorList // errors recovered from inside tree
+	inProgress bool      // entered but not exited yet. used as a seed by left-recursive calls
+	leftRec    bool      // called left-recursively
+}
+
+type stack[T comparable] []ele[T]
 
-func (st stack) isEmpty() bool {
+func (st stack[T]) isEmpty() bool {
 	return len(st) == 0
 }
 
-func (st stack) peek() ele {
+func (st stack[T]) peek() ele[T] {
 	return st[len(st)-1]
 }
 
-func (st *stack) pop() ele {
+func (st stack[T]) top() *ele[T] {
+	return &st[len(st)-1]
+}
+
+func (st *stack[T]) pop() ele[T] {
 	l := len(*st)
 	e := (*st)[l-1]
 	*st = (*st)[:l-1]
 	return e
 }
 
-func (st *stack) push(e ele) {
+func (st *stack[T]) push(e ele[T]) {
 	*st = append(*st, e)
 }
 
+// nonTerms returns symbols of the non-terminals in st, bottom first.
+func (st stack[T]) nonTerms() []interface{} {
+	symbols := make([]interface{}, len(st))
+	for i, e := range st {
+		symbols[i] = e.nonTerm.Symbol
+	}
+	return symbols
+}
+
 type debugStack []*DebugTree
 
 func (ds debugStack) isEmpty() bool {
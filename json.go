@@ -0,0 +1,584 @@
+package rd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SymbolCodec converts symbols of parse trees (non-terminals and tokens) and
+// tokens in debug tree events to and from JSON.
+type SymbolCodec interface {
+	EncodeSymbol(symbol interface{}) ([]byte, error)
+	DecodeSymbol(data []byte) (interface{}, error)
+}
+
+// DefaultSymbolCodec encodes symbols using json.Marshal, and decodes them into the
+// types json.Unmarshal uses for interface{} values. So strings round-trip, but
+// other types of tokens (ex. enums) don't. See SymbolTable for those.
+var DefaultSymbolCodec SymbolCodec = defaultSymbolCodec{}
+
+type defaultSymbolCodec struct{}
+
+func (defaultSymbolCodec) EncodeSymbol(symbol interface{}) ([]byte, error) {
+	return json.Marshal(symbol)
+}
+
+func (defaultSymbolCodec) DecodeSymbol(data []byte) (symbol interface{}, err error) {
+	err = json.Unmarshal(data, &symbol)
+	return
+}
+
+// SymbolTable is a SymbolCodec for symbols with unique names, ex. token enums.
+// Symbols in the table are encoded as their names (JSON strings), and names are
+// decoded back into them. Other symbols are left to Fallback. Note that strings
+// equal to a name in the table are decoded as the table's symbol.
+type SymbolTable struct {
+	// Fallback encodes and decodes symbols that aren't in the table. If nil,
+	// DefaultSymbolCodec is used.
+	Fallback SymbolCodec
+	names    map[interface{}]string
+	symbols  map[string]interface{}
+}
+
+// NewSymbolTable returns a SymbolTable with symbols, named as printed by fmt.Sprint
+// (ex. using their String method).
+func NewSymbolTable(symbols ...interface{}) *SymbolTable {
+	st := &SymbolTable{names: map[interface{}]string{}, symbols: map[string]interface{}{}}
+	for _, symbol := range symbols {
+		st.Add(fmt.Sprint(symbol), symbol)
+	}
+	return st
+}
+
+// Add adds symbol to the table with name. It panics if name is already taken by
+// another symbol.
+func (st *SymbolTable) Add(name string, symbol interface{}) {
+	if other, ok := st.symbols[name]; ok && other != symbol {
+		panic(fmt.Sprintf("symbol name %q is taken by %#v", name, other))
+	}
+	st.names[symbol] = name
+	st.symbols[name] = symbol
+}
+
+func (st *SymbolTable) fallback() SymbolCodec {
+	if st.Fallback == nil {
+		return DefaultSymbolCodec
+	}
+	return st.Fallback
+}
+
+func (st *SymbolTable) EncodeSymbol(symbol interface{}) ([]byte, error) {
+	if name, ok := st.names[symbol]; ok {
+		return json.Marshal(name)
+	}
+	return st.fallback().EncodeSymbol(symbol)
+}
+
+func (st *SymbolTable) DecodeSymbol(data []byte) (interface{}, error) {
+	var name string
+	if json.Unmarshal(data, &name) == nil {
+		if symbol, ok := st.symbols[name]; ok {
+			return symbol, nil
+		}
+	}
+	return st.fallback().DecodeSymbol(data)
+}
+
+// jsonSpan is Span in JSON. Positions are omitted if they aren't valid.
+type jsonSpan struct {
+	Start    int  `json:"start"`
+	End      int  `json:"end"`
+	StartPos *Pos `json:"startPos,omitempty"`
+	EndPos   *Pos `json:"endPos,omitempty"`
+}
+
+func (s Span) MarshalJSON() ([]byte, error) {
+	js := jsonSpan{Start: s.Start, End: s.End}
+	if s.StartPos.IsValid() || s.EndPos.IsValid() {
+		js.StartPos, js.EndPos = &s.StartPos, &s.EndPos
+	}
+	return json.Marshal(js)
+}
+
+func (s *Span) UnmarshalJSON(data []byte) error {
+	var js jsonSpan
+	if err := json.Unmarshal(data, &js); err != nil {
+		return err
+	}
+	*s = Span{Start: js.Start, End: js.End}
+	if js.StartPos != nil {
+		s.StartPos = *js.StartPos
+	}
+	if js.EndPos != nil {
+		s.EndPos = *js.EndPos
+	}
+	return nil
+}
+
+// jsonTree is a TreeOf node in JSON. Span is omitted if it's the zero Span, and
+// Subtrees if there are none. Nodes with an ErrorSymbol have Error instead of
+// Symbol.
+type jsonTree struct {
+	Symbol   json.RawMessage `json:"symbol,omitempty"`
+	Error    json.RawMessage `json:"error,omitempty"`
+	Span     *Span           `json:"span,omitempty"`
+	Subtrees []*jsonTree     `json:"subtrees,omitempty"`
+}
+
+// jsonParsingError is the ParsingError of an ErrorSymbol in JSON. Tokens and
+// non-terminals are encoded using the tree's codec.
+type jsonParsingError struct {
+	Index    int               `json:"index"`
+	Token    json.RawMessage   `json:"token,omitempty"`
+	Expected []jsonExpected    `json:"expected,omitempty"`
+	NonTerms []json.RawMessage `json:"nonTerms,omitempty"`
+}
+
+// jsonExpected is an expected token, or Label if it's a Label.
+type jsonExpected struct {
+	Token json.RawMessage `json:"token,omitempty"`
+	Label string          `json:"label,omitempty"`
+}
+
+// MarshalJSON encodes t using DefaultSymbolCodec. See EncodeTree.
+func (t *TreeOf[T]) MarshalJSON() ([]byte, error) {
+	return TreeJSON[T]{Tree: t}.MarshalJSON()
+}
+
+// UnmarshalJSON decodes t using DefaultSymbolCodec. See DecodeTree.
+func (t *TreeOf[T]) UnmarshalJSON(data []byte) error {
+	tj := TreeJSON[T]{Tree: t}
+	return tj.UnmarshalJSON(data)
+}
+
+// TreeJSON is a TreeOf that's encoded to and decoded from JSON using Codec. It
+// helps use a SymbolCodec with json.Marshal and json.Unmarshal, ex. for trees
+// inside other values. If Codec is nil, DefaultSymbolCodec is used.
+type TreeJSON[T any] struct {
+	Tree  *TreeOf[T]
+	Codec SymbolCodec
+}
+
+func (tj TreeJSON[T]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	if err := encodeTree(w, tj.Tree, codecOrDefault(tj.Codec)); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (tj *TreeJSON[T]) UnmarshalJSON(data []byte) error {
+	var jt *jsonTree
+	if err := json.Unmarshal(data, &jt); err != nil {
+		return err
+	}
+	t, err := decodeTree[T](jt, codecOrDefault(tj.Codec))
+	if err != nil {
+		return err
+	}
+	if tj.Tree == nil || t == nil {
+		tj.Tree = t
+	} else {
+		*tj.Tree = *t
+	}
+	return nil
+}
+
+func codecOrDefault(codec SymbolCodec) SymbolCodec {
+	if codec == nil {
+		return DefaultSymbolCodec
+	}
+	return codec
+}
+
+// EncodeTree writes t to w as JSON, followed by a newline. Each node is an object
+// with the node's symbol encoded using codec, its span, and its subtrees:
+//
+//	{"symbol":"Expr","span":{"start":0,"end":1},"subtrees":[{"symbol":"x",...}]}
+//
+// Nodes inserted during error recovery have the ParsingError of their ErrorSymbol
+// instead of a symbol, whatever the codec:
+//
+//	{"error":{"index":2,"token":"x","expected":[{"token":";"}],"nonTerms":["Block"]},...}
+//
+// Nodes are written as they're encoded, so the JSON for a large tree isn't held
+// in memory. If codec is nil, DefaultSymbolCodec is used.
+func EncodeTree[T any](w io.Writer, t *TreeOf[T], codec SymbolCodec) error {
+	bw := bufio.NewWriter(w)
+	if err := encodeTree(bw, t, codecOrDefault(codec)); err != nil {
+		return err
+	}
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
+
+func encodeTree[T any](w *bufio.Writer, t *TreeOf[T], codec SymbolCodec) error {
+	if t == nil {
+		_, err := w.WriteString("null")
+		return err
+	}
+	if s, ok := t.Symbol.(ErrorSymbol); ok {
+		jpe, err := encodeParsingError(s.Err, codec)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(jpe)
+		if err != nil {
+			return err
+		}
+		w.WriteString(`{"error":`)
+		w.Write(data)
+	} else {
+		symbol, err := codec.EncodeSymbol(t.Symbol)
+		if err != nil {
+			return err
+		}
+		w.WriteString(`{"symbol":`)
+		w.Write(symbol)
+	}
+	if t.Span != (Span{}) {
+		span, err := t.Span.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		w.WriteString(`,"span":`)
+		w.Write(span)
+	}
+	if len(t.Subtrees) > 0 {
+		w.WriteString(`,"subtrees":[`)
+		for i, st := range t.Subtrees {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := encodeTree(w, st, codec); err != nil {
+				return err
+			}
+		}
+		w.WriteByte(']')
+	}
+	_, err := w.WriteString("}")
+	return err
+}
+
+func encodeParsingError(e *ParsingError, codec SymbolCodec) (jpe *jsonParsingError, err error) {
+	if e == nil {
+		return nil, nil
+	}
+	encode := func(symbol interface{}) json.RawMessage {
+		if symbol == nil || err != nil {
+			return nil
+		}
+		var data []byte
+		data, err = codec.EncodeSymbol(symbol)
+		return data
+	}
+	jpe = &jsonParsingError{Index: e.Index, Token: encode(e.Token)}
+	for _, token := range e.Expected {
+		if label, ok := token.(Label); ok {
+			jpe.Expected = append(jpe.Expected, jsonExpected{Label: string(label)})
+		} else {
+			jpe.Expected = append(jpe.Expected, jsonExpected{Token: encode(token)})
+		}
+	}
+	for _, nonTerm := range e.NonTerms {
+		jpe.NonTerms = append(jpe.NonTerms, encode(nonTerm))
+	}
+	return jpe, err
+}
+
+// DecodeTree reads a tree written by EncodeTree from r, decoding symbols using
+// codec. If codec is nil, DefaultSymbolCodec is used.
+func DecodeTree[T any](r io.Reader, codec SymbolCodec) (*TreeOf[T], error) {
+	var jt *jsonTree
+	if err := json.NewDecoder(r).Decode(&jt); err != nil {
+		return nil, err
+	}
+	return decodeTree[T](jt, codecOrDefault(codec))
+}
+
+func decodeTree[T any](jt *jsonTree, codec SymbolCodec) (*TreeOf[T], error) {
+	if jt == nil {
+		return nil, nil
+	}
+	var symbol interface{}
+	var err error
+	if jt.Error != nil {
+		var jpe *jsonParsingError
+		if err := json.Unmarshal(jt.Error, &jpe); err != nil {
+			return nil, err
+		}
+		var e *ParsingError
+		e, err = decodeParsingError(jpe, codec)
+		symbol = ErrorSymbol{Err: e}
+	} else {
+		symbol, err = codec.DecodeSymbol(jt.Symbol)
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := &TreeOf[T]{Symbol: symbol}
+	if jt.Span != nil {
+		t.Span = *jt.Span
+	}
+	for _, jst := range jt.Subtrees {
+		st, err := decodeTree[T](jst, codec)
+		if err != nil {
+			return nil, err
+		}
+		t.Subtrees = append(t.Subtrees, st)
+	}
+	return t, nil
+}
+
+func decodeParsingError(jpe *jsonParsingError, codec SymbolCodec) (e *ParsingError, err error) {
+	if jpe == nil {
+		return nil, nil
+	}
+	decode := func(data json.RawMessage) interface{} {
+		if data == nil || err != nil {
+			return nil
+		}
+		var symbol interface{}
+		symbol, err = codec.DecodeSymbol(data)
+		return symbol
+	}
+	e = &ParsingError{Index: jpe.Index, Token: decode(jpe.Token)}
+	for _, je := range jpe.Expected {
+		if je.Label != "" {
+			e.Expected = append(e.Expected, Label(je.Label))
+		} else {
+			e.Expected = append(e.Expected, decode(je.Token))
+		}
+	}
+	for _, nonTerm := range jpe.NonTerms {
+		e.NonTerms = append(e.NonTerms, decode(nonTerm))
+	}
+	return e, err
+}
+
+// jsonEvent is a debug tree event in JSON. Type is the kind of event ("enter",
+// "exit", "match" or "backtrack"), or "text" for events that are strings. Fields
+// that don't apply to the event, or are zero, are omitted.
+type jsonEvent struct {
+	Type      string          `json:"type"`
+	NonTerm   json.RawMessage `json:"nonTerm,omitempty"`
+	Index     int             `json:"index,omitempty"`
+	Start     int             `json:"start,omitempty"`
+	End       int             `json:"end,omitempty"`
+	From      int             `json:"from,omitempty"`
+	To        int             `json:"to,omitempty"`
+	Result    bool            `json:"result,omitempty"`
+	Skip      bool            `json:"skip,omitempty"`
+	Recovered bool            `json:"recovered,omitempty"`
+	MemoHit   bool            `json:"memoHit,omitempty"`
+	Token     json.RawMessage `json:"token,omitempty"`
+	NoTokens  bool            `json:"noTokens,omitempty"`
+	Want      json.RawMessage `json:"want,omitempty"`
+	// WantLabel is set instead of Want if it's a Label
+	WantLabel string `json:"wantLabel,omitempty"`
+	OK        bool   `json:"ok,omitempty"`
+	GrowSeed  bool   `json:"growSeed,omitempty"`
+	Text      string `json:"text,omitempty"`
+}
+
+type jsonDebugTree struct {
+	Event    jsonEvent        `json:"event"`
+	Subtrees []*jsonDebugTree `json:"subtrees,omitempty"`
+}
+
+// MarshalJSON encodes dt using DefaultSymbolCodec. See EncodeDebugTree.
+func (dt *DebugTree) MarshalJSON() ([]byte, error) {
+	return DebugTreeJSON{Tree: dt}.MarshalJSON()
+}
+
+// UnmarshalJSON decodes dt using DefaultSymbolCodec. See DecodeDebugTree.
+func (dt *DebugTree) UnmarshalJSON(data []byte) error {
+	dtj := DebugTreeJSON{Tree: dt}
+	return dtj.UnmarshalJSON(data)
+}
+
+// DebugTreeJSON is a DebugTree that's encoded to and decoded from JSON using
+// Codec. See TreeJSON.
+type DebugTreeJSON struct {
+	Tree  *DebugTree
+	Codec SymbolCodec
+}
+
+func (dtj DebugTreeJSON) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	if err := encodeDebugTree(w, dtj.Tree, codecOrDefault(dtj.Codec)); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (dtj *DebugTreeJSON) UnmarshalJSON(data []byte) error {
+	var jdt *jsonDebugTree
+	if err := json.Unmarshal(data, &jdt); err != nil {
+		return err
+	}
+	dt, err := decodeDebugTree(jdt, codecOrDefault(dtj.Codec))
+	if err != nil {
+		return err
+	}
+	if dtj.Tree == nil || dt == nil {
+		dtj.Tree = dt
+	} else {
+		*dtj.Tree = *dt
+	}
+	return nil
+}
+
+// EncodeDebugTree writes dt to w as JSON, followed by a newline. Each node is an
+// object with the node's event, and its subtrees:
+//
+//	{"event":{"type":"exit","nonTerm":"Expr","end":1,"result":true},"subtrees":[...]}
+//
+// Non-terminals and tokens in events are encoded using codec. If codec is nil,
+// DefaultSymbolCodec is used. Like EncodeTree, nodes are written as they're
+// encoded.
+func EncodeDebugTree(w io.Writer, dt *DebugTree, codec SymbolCodec) error {
+	bw := bufio.NewWriter(w)
+	if err := encodeDebugTree(bw, dt, codecOrDefault(codec)); err != nil {
+		return err
+	}
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
+
+func encodeDebugTree(w *bufio.Writer, dt *DebugTree, codec SymbolCodec) error {
+	if dt == nil {
+		_, err := w.WriteString("null")
+		return err
+	}
+	je, err := encodeEvent(dt.Event, codec)
+	if err != nil {
+		return err
+	}
+	event, err := json.Marshal(je)
+	if err != nil {
+		return err
+	}
+	w.WriteString(`{"event":`)
+	w.Write(event)
+	if len(dt.Subtrees) > 0 {
+		w.WriteString(`,"subtrees":[`)
+		for i, st := range dt.Subtrees {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := encodeDebugTree(w, st, codec); err != nil {
+				return err
+			}
+		}
+		w.WriteByte(']')
+	}
+	_, err = w.WriteString("}")
+	return err
+}
+
+func encodeEvent(event interface{}, codec SymbolCodec) (je jsonEvent, err error) {
+	encode := func(symbol interface{}) json.RawMessage {
+		if symbol == nil || err != nil {
+			return nil
+		}
+		var data []byte
+		data, err = codec.EncodeSymbol(symbol)
+		return data
+	}
+	switch e := event.(type) {
+	case EnterEvent:
+		je = jsonEvent{Type: "enter", NonTerm: encode(e.NonTerm), Index: e.Index}
+	case ExitEvent:
+		je = jsonEvent{Type: "exit", NonTerm: encode(e.NonTerm), Start: e.Start, End: e.End, Result: e.Result,
+			Skip: e.Skip, Recovered: e.Recovered, MemoHit: e.MemoHit}
+	case MatchEvent:
+		je = jsonEvent{Type: "match", Index: e.Index, Token: encode(e.Token), NoTokens: e.NoTokens, OK: e.OK}
+		if label, ok := e.Want.(Label); ok {
+			je.WantLabel = string(label)
+		} else {
+			je.Want = encode(e.Want)
+		}
+	case BacktrackEvent:
+		je = jsonEvent{Type: "backtrack", NonTerm: encode(e.NonTerm), From: e.From, To: e.To, GrowSeed: e.GrowSeed}
+	case string:
+		je = jsonEvent{Type: "text", Text: e}
+	default:
+		return je, fmt.Errorf("can't encode debug tree event of type %T", event)
+	}
+	return je, err
+}
+
+// DecodeDebugTree reads a debug tree written by EncodeDebugTree from r, decoding
+// non-terminals and tokens using codec. If codec is nil, DefaultSymbolCodec is
+// used.
+func DecodeDebugTree(r io.Reader, codec SymbolCodec) (*DebugTree, error) {
+	var jdt *jsonDebugTree
+	if err := json.NewDecoder(r).Decode(&jdt); err != nil {
+		return nil, err
+	}
+	return decodeDebugTree(jdt, codecOrDefault(codec))
+}
+
+func decodeDebugTree(jdt *jsonDebugTree, codec SymbolCodec) (*DebugTree, error) {
+	if jdt == nil {
+		return nil, nil
+	}
+	event, err := decodeEvent(jdt.Event, codec)
+	if err != nil {
+		return nil, err
+	}
+	dt := &DebugTree{Event: event}
+	for _, jst := range jdt.Subtrees {
+		st, err := decodeDebugTree(jst, codec)
+		if err != nil {
+			return nil, err
+		}
+		dt.Subtrees = append(dt.Subtrees, st)
+	}
+	return dt, nil
+}
+
+func decodeEvent(je jsonEvent, codec SymbolCodec) (event interface{}, err error) {
+	decode := func(data json.RawMessage) interface{} {
+		if data == nil || err != nil {
+			return nil
+		}
+		var symbol interface{}
+		symbol, err = codec.DecodeSymbol(data)
+		return symbol
+	}
+	switch je.Type {
+	case "enter":
+		event = EnterEvent{NonTerm: decode(je.NonTerm), Index: je.Index}
+	case "exit":
+		event = ExitEvent{NonTerm: decode(je.NonTerm), Start: je.Start, End: je.End, Result: je.Result,
+			Skip: je.Skip, Recovered: je.Recovered, MemoHit: je.MemoHit}
+	case "match":
+		e := MatchEvent{Index: je.Index, Token: decode(je.Token), NoTokens: je.NoTokens, OK: je.OK}
+		if je.WantLabel != "" {
+			e.Want = Label(je.WantLabel)
+		} else {
+			e.Want = decode(je.Want)
+		}
+		event = e
+	case "backtrack":
+		event = BacktrackEvent{NonTerm: decode(je.NonTerm), From: je.From, To: je.To, GrowSeed: je.GrowSeed}
+	case "text":
+		event = je.Text
+	default:
+		return nil, fmt.Errorf("unknown debug tree event type %q", je.Type)
+	}
+	return event, err
+}
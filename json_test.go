@@ -0,0 +1,154 @@
+package rd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testToken int
+
+const (
+	testPlus testToken = iota
+	testMinus
+)
+
+func (t testToken) String() string {
+	return [...]string{"+", "-"}[t]
+}
+
+// memoHitExpr parses an Expr that parses a memoized Term matching any token twice,
+// the second time from the memo, before parsing rest.
+func memoHitExpr(b *Builder, rest func() bool) (ok bool) {
+	defer b.Enter("Expr").Exit(&ok)
+	start := b.Mark()
+	for i := 0; i < 2; i++ {
+		func() (ok bool) {
+			defer b.Enter("Term").Exit(&ok)
+			return b.Memo(func() bool {
+				return b.MatchFunc(func(Token) bool { return true }, "any")
+			})
+		}()
+		b.Reset(start)
+	}
+	return rest()
+}
+
+func TestTree_JSON(t *testing.T) {
+	tree := NewTree("Expr", NewTree("x"), NewTree("+"), NewTree("y"))
+	tree.Span = Span{Start: 0, End: 3, StartPos: Pos{Line: 1, Column: 1}, EndPos: Pos{Offset: 3, Line: 1, Column: 4}}
+	tree.Subtrees[0].Span = Span{Start: 0, End: 1}
+
+	data, err := json.Marshal(tree)
+	assert.Nil(t, err)
+	expected := `{"symbol":"Expr","span":{"start":0,"end":3,"startPos":{"offset":0,"line":1,"column":1},` +
+		`"endPos":{"offset":3,"line":1,"column":4}},"subtrees":[{"symbol":"x","span":{"start":0,"end":1}},` +
+		`{"symbol":"+"},{"symbol":"y"}]}`
+	assert.Equal(t, expected, string(data))
+
+	var got *Tree
+	assert.Nil(t, json.Unmarshal(data, &got))
+	assert.Equal(t, tree, got)
+
+	var buf bytes.Buffer
+	assert.Nil(t, EncodeTree(&buf, tree, nil))
+	assert.Equal(t, expected+"\n", buf.String())
+	got, err = DecodeTree[Token](&buf, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, tree, got)
+}
+
+func TestSymbolTable(t *testing.T) {
+	codec := NewSymbolTable(testPlus, testMinus)
+	tree := NewTree("Expr", NewTree("x"), NewTree(testPlus), NewTree(2))
+
+	data, err := json.Marshal(TreeJSON[Token]{Tree: tree, Codec: codec})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"symbol":"Expr","subtrees":[{"symbol":"x"},{"symbol":"+"},{"symbol":2}]}`, string(data))
+
+	got := TreeJSON[Token]{Codec: codec}
+	assert.Nil(t, json.Unmarshal(data, &got))
+	assert.Equal(t, NewTree("Expr", NewTree("x"), NewTree(testPlus), NewTree(2.0)), got.Tree)
+
+	assert.Panics(t, func() { codec.Add("+", "plus") })
+}
+
+func TestTree_JSON_ErrorSymbol(t *testing.T) {
+	// Stmts = Stmt {";" Stmt}
+	// Stmt  = "x" | number
+	b := NewBuilder([]Token{"x", ";", testPlus, "1", ";", "2"})
+	func() (ok bool) {
+		defer b.Enter("Stmts").Exit(&ok)
+		stmt := func() (ok bool) {
+			defer b.Enter("Stmt").Sync(";").Exit(&ok)
+			return b.Match("x") || b.MatchFunc(func(token Token) bool {
+				s, ok := token.(string)
+				return ok && s >= "0" && s <= "9"
+			}, "number")
+		}
+		if !stmt() {
+			return false
+		}
+		for b.Match(";") {
+			if !stmt() {
+				return false
+			}
+		}
+		return true
+	}()
+	tree := b.ParseTree()
+	expected := `Stmts
+├─ Stmt
+│  └─ x
+├─ ;
+├─ Stmt
+│  └─ <error>
+│     ├─ +
+│     └─ 1
+├─ ;
+└─ Stmt
+   └─ 2
+`
+	if !assert.Equal(t, expected, tree.String()) {
+		return
+	}
+
+	codec := NewSymbolTable(testPlus, testMinus)
+	var buf bytes.Buffer
+	assert.Nil(t, EncodeTree(&buf, tree, codec))
+	assert.Contains(t, buf.String(), `{"error":{"index":2,"token":"+","expected":[{"token":"x"},{"label":"number"}],"nonTerms":["Stmts","Stmt"]},`)
+	got, err := DecodeTree[Token](&buf, codec)
+	assert.Nil(t, err)
+	assert.Equal(t, tree, got)
+	assert.Equal(t, expected, got.String())
+	assert.EqualError(t, got.Subtrees[2].Subtrees[0].Symbol.(ErrorSymbol).Err, b.Errs()[0].Error())
+
+	data, err := json.Marshal(NewTree(ErrorSymbol{}))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"error":null}`, string(data))
+	got = nil
+	assert.Nil(t, json.Unmarshal(data, &got))
+	assert.Equal(t, NewTree(ErrorSymbol{}), got)
+}
+
+func TestDebugTree_JSON(t *testing.T) {
+	codec := NewSymbolTable(testPlus, testMinus)
+	b := NewBuilder([]Token{"x", testPlus}, Memoize(), DebugLast(8))
+	memoHitExpr(b, func() bool {
+		return b.Match("x") && b.Match(testMinus)
+	})
+	dt := b.DebugTree()
+
+	var buf bytes.Buffer
+	assert.Nil(t, EncodeDebugTree(&buf, dt, codec))
+	got, err := DecodeDebugTree(&buf, codec)
+	assert.Nil(t, err)
+	assert.Equal(t, dt, got)
+	assert.Equal(t, "<last 8 events>\n└─ Expr(false)\n   ├─ Term(true)\n   │  └─ x = <any>\n   ├─ Term(true)\n   │  └─ <memo hit>\n   ├─ x = x\n   └─ + ≠ -\n", got.String())
+
+	_, err = json.Marshal(&DebugTree{Event: 1})
+	assert.EqualError(t, err, "json: error calling MarshalJSON for type *rd.DebugTree: can't encode debug tree event of type int")
+	assert.EqualError(t, json.Unmarshal([]byte(`{"event":{"type":"x"}}`), &DebugTree{}), `unknown debug tree event type "x"`)
+}
@@ -0,0 +1,204 @@
+package rd
+
+import (
+	"fmt"
+	"math"
+)
+
+// Assoc is the associativity of infix operators. It decides how operators of the
+// same precedence group.
+type Assoc int
+
+const (
+	// LeftAssoc operators group from the left: a - b - c is (a - b) - c.
+	LeftAssoc Assoc = iota
+	// RightAssoc operators group from the right: a ^ b ^ c is a ^ (b ^ c).
+	RightAssoc
+)
+
+type operator struct {
+	precedence int
+	assoc      Assoc
+}
+
+// OperatorTable declares the operators of expressions parsed by Builder's
+// Operators method, with their precedence levels. Operators with a higher
+// precedence bind tighter. A token can be both a prefix operator and an infix or
+// postfix operator (ex. "-"), but not both an infix and a postfix operator.
+//
+// ex.
+//
+//	ops := rd.NewOperatorTable[rd.Token]().
+//		Infix(1, rd.LeftAssoc, "+", "-").
+//		Infix(2, rd.LeftAssoc, "*", "/").
+//		Prefix(3, "-").
+//		Infix(4, rd.RightAssoc, "^").
+//		Postfix(5, "!")
+type OperatorTable[T comparable] struct {
+	// PrefixSymbol, InfixSymbol and PostfixSymbol are the symbols of the
+	// non-terminals added to the parse tree for operators. They default to
+	// "Prefix", "Infix" and "Postfix".
+	PrefixSymbol, InfixSymbol, PostfixSymbol interface{}
+	prefix, infix, postfix                   map[T]operator
+}
+
+// NewOperatorTable returns an empty OperatorTable.
+func NewOperatorTable[T comparable]() *OperatorTable[T] {
+	return &OperatorTable[T]{
+		PrefixSymbol:  "Prefix",
+		InfixSymbol:   "Infix",
+		PostfixSymbol: "Postfix",
+		prefix:        map[T]operator{},
+		infix:         map[T]operator{},
+		postfix:       map[T]operator{},
+	}
+}
+
+// Prefix declares tokens as prefix operators with precedence. Their operand
+// includes the operators with a higher precedence: with "-" binding tighter
+// than "*", and "^" tighter than "-", -a * b is (-a) * b, and -a ^ b is -(a ^ b).
+func (ot *OperatorTable[T]) Prefix(precedence int, tokens ...T) *OperatorTable[T] {
+	for _, token := range tokens {
+		ot.prefix[token] = operator{precedence: precedence}
+	}
+	return ot
+}
+
+// Infix declares tokens as infix operators with precedence and assoc. It panics if
+// one of them is a postfix operator.
+func (ot *OperatorTable[T]) Infix(precedence int, assoc Assoc, tokens ...T) *OperatorTable[T] {
+	for _, token := range tokens {
+		if _, ok := ot.postfix[token]; ok {
+			panic(fmt.Sprintf("operator %v is already a postfix operator", token))
+		}
+		ot.infix[token] = operator{precedence: precedence, assoc: assoc}
+	}
+	return ot
+}
+
+// Postfix declares tokens as postfix operators with precedence. It panics if one
+// of them is an infix operator.
+func (ot *OperatorTable[T]) Postfix(precedence int, tokens ...T) *OperatorTable[T] {
+	for _, token := range tokens {
+		if _, ok := ot.infix[token]; ok {
+			panic(fmt.Sprintf("operator %v is already an infix operator", token))
+		}
+		ot.postfix[token] = operator{precedence: precedence}
+	}
+	return ot
+}
+
+// Operators parses an expression made of operands and the operators in table,
+// using precedence climbing. operand parses operands, and is usually a
+// non-terminal function (ex. for numbers, identifiers and parenthesized
+// expressions). Operators are added to the parse tree as non-terminals (see
+// table's symbols) holding the operator and its operands, nested according to
+// precedence and associativity. ex. for 1 + 2 * 3, with "*" binding tighter than
+// "+":
+//
+//	Expr
+//	└─ Infix
+//	   ├─ 1
+//	   ├─ +
+//	   └─ Infix
+//	      ├─ 2
+//	      ├─ *
+//	      └─ 3
+//
+// Like any other non-terminals, they're traced, and can have semantic actions (see
+// Actions). Since an infix or postfix operator is only known once its left operand
+// has been parsed, it's entered after it, and its left operand is moved under it.
+// Tracers are notified of the Enter then, with the index its left operand started
+// at, so in the debug tree the left operand comes right before the operator:
+//
+//	Expr(true)
+//	├─ 1 = <number>
+//	└─ Infix(true)
+//	   ├─ + = +
+//	   └─ ...
+//
+// Operators must be called inside a non-terminal function, ex.
+//
+//	func Expr(b *rd.Builder) (ok bool) {
+//		defer b.Enter("Expr").Exit(&ok)
+//
+//		return b.Operators(ops, Operand)
+//	}
+func (b *BuilderOf[T]) Operators(table *OperatorTable[T], operand func(b *BuilderOf[T]) bool) bool {
+	b.mustEnter("Operators")
+	return b.climb(table, operand, math.MinInt)
+}
+
+// climb parses an operand, optionally preceded by prefix operators, and followed
+// by infix and postfix operators with a precedence of at least minPrecedence.
+func (b *BuilderOf[T]) climb(table *OperatorTable[T], operand func(b *BuilderOf[T]) bool, minPrecedence int) bool {
+	start := b.Mark()
+	if !b.prefixed(table, operand) {
+		return false
+	}
+	for {
+		next, ok := b.lookahead()
+		if !ok {
+			return true
+		}
+		if op, ok := table.infix[next]; ok && op.precedence >= minPrecedence {
+			rightPrecedence := op.precedence + 1
+			if op.assoc == RightAssoc {
+				rightPrecedence = op.precedence
+			}
+			if !b.applyOperator(table.InfixSymbol, start, next, func() bool {
+				return b.climb(table, operand, rightPrecedence)
+			}) {
+				return false
+			}
+		} else if op, ok := table.postfix[next]; ok && op.precedence >= minPrecedence {
+			if !b.applyOperator(table.PostfixSymbol, start, next, nil) {
+				return false
+			}
+		} else {
+			return true
+		}
+	}
+}
+
+// prefixed parses an operand, optionally preceded by prefix operators. If the
+// next token is a prefix operator that can't be parsed as one, it's left to
+// operand.
+func (b *BuilderOf[T]) prefixed(table *OperatorTable[T], operand func(b *BuilderOf[T]) bool) bool {
+	if next, ok := b.lookahead(); ok {
+		if op, ok := table.prefix[next]; ok {
+			b.Enter(table.PrefixSymbol)
+			ok := b.Match(next) && b.climb(table, operand, op.precedence)
+			b.Exit(&ok)
+			if ok {
+				return true
+			}
+		}
+	}
+	return operand(b)
+}
+
+// applyOperator enters a non-terminal for an infix or postfix operator at start,
+// moves the symbols added to the current non-terminal since start (the left
+// operand) under it, and matches the operator followed by the right operand, if
+// right isn't nil. On failure, the current index is reset to start, like for any
+// failed non-terminal.
+func (b *BuilderOf[T]) applyOperator(symbol interface{}, start Mark, token T, right func() bool) bool {
+	parent := b.stack.top().nonTerm
+	left := append([]*TreeOf[T]{}, parent.Subtrees[start.subtrees:]...)
+	parent.Subtrees = parent.Subtrees[:start.subtrees:start.subtrees]
+
+	b.enter(symbol, start.index)
+	b.stack.top().nonTerm.Subtrees = left
+	ok := b.Match(token) && (right == nil || right())
+	b.Exit(&ok)
+	return ok
+}
+
+// lookahead returns the next token, without tracing a Peek.
+func (b *BuilderOf[T]) lookahead() (token T, ok bool) {
+	if i := b.current + 1; i < len(b.tokens) {
+		return b.tokens[i], true
+	}
+	return token, false
+}
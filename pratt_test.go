@@ -0,0 +1,178 @@
+package rd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testOperators = NewOperatorTable[Token]().
+	Infix(1, LeftAssoc, "+", "-").
+	Infix(2, LeftAssoc, "*", "/").
+	Prefix(3, "-").
+	Infix(4, RightAssoc, "^").
+	Postfix(5, "!")
+
+// Expr    = operators over Operand
+// Operand = Group | number
+// Group   = "(" Expr ")"
+func testExpr(b *Builder) (ok bool) {
+	defer b.Enter("Expr").Exit(&ok)
+	return b.Operators(testOperators, func(b *Builder) bool {
+		return testGroup(b) || b.MatchFunc(func(token Token) bool {
+			_, ok := token.(int)
+			return ok
+		}, "number")
+	})
+}
+
+func testGroup(b *Builder) (ok bool) {
+	defer b.Enter("Group").Exit(&ok)
+	return b.Match("(") && testExpr(b) && b.Match(")")
+}
+
+// sexpr prints operators in parse trees built by testExpr with parentheses.
+func sexpr(t *Tree) string {
+	switch t.Symbol {
+	case "Expr":
+		return sexpr(t.Subtrees[0])
+	case "Group":
+		return sexpr(t.Subtrees[1])
+	case "Prefix", "Infix", "Postfix":
+		var s []string
+		for _, st := range t.Subtrees {
+			s = append(s, sexpr(st))
+		}
+		return "(" + strings.Join(s, " ") + ")"
+	}
+	return fmt.Sprint(t.Symbol)
+}
+
+func testTokens(s string) (tokens []Token) {
+	for _, f := range strings.Fields(s) {
+		var n int
+		if _, err := fmt.Sscan(f, &n); err == nil {
+			tokens = append(tokens, n)
+		} else {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
+
+func TestOperators(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"1", "1"},
+		{"1 + 2 * 3", "(1 + (2 * 3))"},
+		{"1 * 2 + 3", "((1 * 2) + 3)"},
+		{"1 - 2 - 3", "((1 - 2) - 3)"},
+		{"2 ^ 3 ^ 2", "(2 ^ (3 ^ 2))"},
+		{"- 2 * 3", "((- 2) * 3)"},
+		{"- 2 ^ 2", "(- (2 ^ 2))"},
+		{"- - 2", "(- (- 2))"},
+		{"3 ! ^ 2", "((3 !) ^ 2)"},
+		{"- 3 !", "(- (3 !))"},
+		{"( 1 + 2 ) * 3", "((1 + 2) * 3)"},
+		{"1 - - 2 * 3 !", "(1 - ((- 2) * (3 !)))"},
+	}
+	for _, test := range tests {
+		b := NewBuilder(testTokens(test.input))
+		if assert.True(t, testExpr(b), test.input) {
+			assert.Equal(t, test.expected, sexpr(b.ParseTree()), test.input)
+		}
+	}
+}
+
+func TestOperators_Tree(t *testing.T) {
+	r := &recordingTracer{}
+	b := NewBuilder(testTokens("1 + 2 * 3"), Trace(r))
+	assert.True(t, testExpr(b))
+	tree := b.ParseTree()
+	expected := `Expr
+└─ Infix
+   ├─ 1
+   ├─ +
+   └─ Infix
+      ├─ 2
+      ├─ *
+      └─ 3
+`
+	assert.Equal(t, expected, tree.String())
+	assert.Equal(t, Span{Start: 0, End: 5}, tree.Subtrees[0].Span)
+	assert.Equal(t, Span{Start: 2, End: 5}, tree.Subtrees[0].Subtrees[2].Span)
+
+	// operators are entered after their left operand, at its start
+	expected = `Expr(true)
+├─ Group(false)
+│  └─ 1 ≠ (
+├─ 1 = <number>
+└─ Infix(true)
+   ├─ + = +
+   ├─ Group(false)
+   │  └─ 2 ≠ (
+   ├─ 2 = <number>
+   └─ Infix(true)
+      ├─ * = *
+      ├─ Group(false)
+      │  └─ 3 ≠ (
+      └─ 3 = <number>
+`
+	assert.Equal(t, expected, b.DebugTree().String())
+	var enters []EnterEvent
+	for _, event := range r.events {
+		switch e := event.(type) {
+		case EnterEvent:
+			enters = append(enters, e)
+		case ExitEvent:
+			enter := enters[len(enters)-1]
+			enters = enters[:len(enters)-1]
+			assert.Equal(t, enter.NonTerm, e.NonTerm)
+			assert.Equal(t, enter.Index, e.Start, e.NonTerm)
+		}
+	}
+	assert.Contains(t, r.events, EnterEvent{NonTerm: "Infix", Index: 2})
+
+	b = NewBuilder(testTokens("1 + * 3"))
+	assert.False(t, testExpr(b))
+	assert.EqualError(t, b.Err(), "expected `(` or <number>, found `*` at token 2 in Group")
+}
+
+func TestOperators_Actions(t *testing.T) {
+	actions := Actions(map[interface{}]Action{
+		"Expr": func(values []interface{}) interface{} {
+			return values[0]
+		},
+		"Infix": func(values []interface{}) interface{} {
+			x, y := values[0].(int), values[2].(int)
+			switch values[1] {
+			case "-":
+				return x - y
+			case "^":
+				z := 1
+				for i := 0; i < y; i++ {
+					z *= x
+				}
+				return z
+			}
+			return x + y
+		},
+	})
+	for input, expected := range map[string]int{"1 - 2 - 3": -4, "2 ^ 3 ^ 2": 512, "1 + 2 - 3": 0} {
+		b := NewBuilder(testTokens(input), actions)
+		assert.True(t, testExpr(b), input)
+		assert.Equal(t, expected, b.ParseTree().Value, input)
+	}
+}
+
+func TestOperatorTable_Conflicts(t *testing.T) {
+	assert.PanicsWithValue(t, "operator ! is already a postfix operator", func() {
+		NewOperatorTable[Token]().Postfix(1, "!").Infix(1, LeftAssoc, "!")
+	})
+	assert.PanicsWithValue(t, "operator ! is already an infix operator", func() {
+		NewOperatorTable[Token]().Infix(1, LeftAssoc, "!").Postfix(1, "!")
+	})
+}
@@ -0,0 +1,302 @@
+package rd
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Profiler is a Tracer that collects statistics per non-terminal, to find the
+// rules a grammar spends its time in. Add it to a Builder using the Trace option.
+// Non-terminals must be comparable.
+//
+// ex.
+//
+//	p := rd.NewProfiler()
+//	b := rd.NewBuilder(tokens, rd.Trace(p))
+//	Expr(b)
+//	p.WriteTable(os.Stdout)
+type Profiler struct {
+	NopTracer
+	stats map[interface{}]*NonTermStats
+	// root of the call tree. Its children are the root non-terminals.
+	root  *callNode
+	stack []profileFrame
+	start time.Time
+	now   func() time.Time
+}
+
+// NonTermStats are statistics of a non-terminal collected by Profiler.
+type NonTermStats struct {
+	NonTerm interface{}
+	// Calls is the number of times the non-terminal exited. Each call either
+	// succeeds or fails, and may be skipped (see Builder's Skip method) or
+	// replayed from memory (see Builder's Memo method).
+	Calls, Successes, Failures, Skips, MemoHits int
+	// Backtracks is the number of calls to Backtrack and Reset.
+	Backtracks int
+	// Tokens is the number of tokens consumed by successful calls.
+	Tokens int
+	// Time is the wall time spent in calls, including nested non-terminals.
+	// Recursive calls are counted once.
+	Time time.Duration
+	// SelfTime is the wall time spent in calls, excluding nested non-terminals.
+	SelfTime time.Duration
+
+	active int
+}
+
+// callNode is a node in the call tree of non-terminals.
+type callNode struct {
+	stats    *NonTermStats
+	parent   *callNode
+	children map[*NonTermStats]*callNode
+	order    []*callNode
+	calls    int64
+	self     time.Duration
+}
+
+func (n *callNode) child(stats *NonTermStats) *callNode {
+	if c, ok := n.children[stats]; ok {
+		return c
+	}
+	c := &callNode{stats: stats, parent: n, children: map[*NonTermStats]*callNode{}}
+	n.children[stats] = c
+	n.order = append(n.order, c)
+	return c
+}
+
+type profileFrame struct {
+	node     *callNode
+	start    time.Time
+	children time.Duration
+}
+
+// NewProfiler returns a new Profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{
+		stats: map[interface{}]*NonTermStats{},
+		root:  &callNode{children: map[*NonTermStats]*callNode{}},
+		now:   time.Now,
+	}
+}
+
+func (p *Profiler) nonTermStats(nonTerm interface{}) *NonTermStats {
+	s, ok := p.stats[nonTerm]
+	if !ok {
+		s = &NonTermStats{NonTerm: nonTerm}
+		p.stats[nonTerm] = s
+	}
+	return s
+}
+
+func (p *Profiler) Enter(e EnterEvent) {
+	now := p.now()
+	parent := p.root
+	if len(p.stack) > 0 {
+		parent = p.stack[len(p.stack)-1].node
+	} else if p.start.IsZero() {
+		p.start = now
+	}
+	s := p.nonTermStats(e.NonTerm)
+	s.active++
+	p.stack = append(p.stack, profileFrame{node: parent.child(s), start: now})
+}
+
+func (p *Profiler) Exit(e ExitEvent) {
+	f := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	elapsed := p.now().Sub(f.start)
+	self := elapsed - f.children
+	if len(p.stack) > 0 {
+		p.stack[len(p.stack)-1].children += elapsed
+	}
+	f.node.calls++
+	f.node.self += self
+
+	s := f.node.stats
+	s.Calls++
+	if e.Result {
+		s.Successes++
+		s.Tokens += e.End - e.Start
+	} else {
+		s.Failures++
+	}
+	if e.Skip {
+		s.Skips++
+	}
+	if e.MemoHit {
+		s.MemoHits++
+	}
+	s.SelfTime += self
+	if s.active--; s.active == 0 {
+		s.Time += elapsed
+	}
+}
+
+func (p *Profiler) Backtrack(e BacktrackEvent) {
+	if !e.GrowSeed {
+		p.nonTermStats(e.NonTerm).Backtracks++
+	}
+}
+
+// Stats returns statistics of the non-terminals entered so far, sorted by
+// decreasing Time.
+func (p *Profiler) Stats() []NonTermStats {
+	stats := make([]NonTermStats, 0, len(p.stats))
+	for _, s := range p.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Time != stats[j].Time {
+			return stats[i].Time > stats[j].Time
+		}
+		if stats[i].Calls != stats[j].Calls {
+			return stats[i].Calls > stats[j].Calls
+		}
+		return fmt.Sprint(stats[i].NonTerm) < fmt.Sprint(stats[j].NonTerm)
+	})
+	return stats
+}
+
+// WriteTable writes Stats as a table, one non-terminal per row.
+func (p *Profiler) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "calls\tsuccesses\tfailures\tskips\tmemo hits\tbacktracks\ttokens\tself\ttime\t non-terminal")
+	for _, s := range p.Stats() {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t%d\t%d\t%v\t%v\t %v\n", s.Calls, s.Successes, s.Failures,
+			s.Skips, s.MemoHits, s.Backtracks, s.Tokens, s.SelfTime, s.Time, s.NonTerm)
+	}
+	return tw.Flush()
+}
+
+// WritePprof writes the profile in the gzipped protobuf format read by
+// go tool pprof. Non-terminals are functions, and the stack of non-terminals
+// entered is the call stack. Samples hold the number of calls, and self wall
+// time.
+//
+// ex.
+//
+//	go tool pprof -top grammar.pprof
+//	go tool pprof -http=: grammar.pprof
+func (p *Profiler) WritePprof(w io.Writer) error {
+	var b protoBuffer
+	stringIDs := map[string]int{}
+	str := func(s string) int {
+		if i, ok := stringIDs[s]; ok {
+			return i
+		}
+		i := len(stringIDs)
+		stringIDs[s] = i
+		return i
+	}
+	str("")
+	valueType := func(typ, unit string) protoBuffer {
+		var vt protoBuffer
+		vt.varintField(1, uint64(str(typ)))
+		vt.varintField(2, uint64(str(unit)))
+		return vt
+	}
+
+	// profile.proto's Profile message
+	b.bytesField(1, valueType("calls", "count"))
+	b.bytesField(1, valueType("time", "nanoseconds"))
+
+	// a function and a location for each non-terminal, in the order they were
+	// first entered
+	ids := map[*NonTermStats]uint64{}
+	var nodes []*callNode
+	var visit func(n *callNode)
+	visit = func(n *callNode) {
+		for _, c := range n.order {
+			nodes = append(nodes, c)
+			if _, ok := ids[c.stats]; !ok {
+				ids[c.stats] = uint64(len(ids) + 1)
+			}
+			visit(c)
+		}
+	}
+	visit(p.root)
+
+	for _, n := range nodes {
+		var sample, locations, values protoBuffer
+		for c := n; c != p.root; c = c.parent {
+			locations.varint(ids[c.stats])
+		}
+		values.varint(uint64(n.calls))
+		values.varint(uint64(n.self))
+		sample.bytesField(1, locations)
+		sample.bytesField(2, values)
+		b.bytesField(2, sample)
+	}
+
+	stats := make([]*NonTermStats, len(ids))
+	for s, id := range ids {
+		stats[id-1] = s
+	}
+	for i := range stats {
+		var location, line protoBuffer
+		line.varintField(1, uint64(i+1))
+		location.varintField(1, uint64(i+1))
+		location.bytesField(4, line)
+		b.bytesField(4, location)
+	}
+	for i, s := range stats {
+		var function protoBuffer
+		name := uint64(str(fmt.Sprint(s.NonTerm)))
+		function.varintField(1, uint64(i+1))
+		function.varintField(2, name)
+		function.varintField(3, name)
+		b.bytesField(5, function)
+	}
+
+	table := make([]string, len(stringIDs))
+	for s, i := range stringIDs {
+		table[i] = s
+	}
+	for _, s := range table {
+		b.bytesField(6, protoBuffer(s))
+	}
+	if !p.start.IsZero() {
+		b.varintField(9, uint64(p.start.UnixNano()))
+		var duration time.Duration
+		for _, n := range p.root.order {
+			duration += n.stats.Time
+		}
+		b.varintField(10, uint64(duration))
+	}
+	b.bytesField(11, valueType("time", "nanoseconds"))
+	b.varintField(12, 1)
+
+	gw := gzip.NewWriter(w)
+	if _, err := gw.Write(b); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// protoBuffer encodes protocol buffer messages. Only the varint and
+// length-delimited wire types are needed for profiles.
+type protoBuffer []byte
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		*b = append(*b, byte(x)|0x80)
+		x >>= 7
+	}
+	*b = append(*b, byte(x))
+}
+
+func (b *protoBuffer) varintField(field int, x uint64) {
+	b.varint(uint64(field) << 3)
+	b.varint(x)
+}
+
+func (b *protoBuffer) bytesField(field int, msg protoBuffer) {
+	b.varint(uint64(field)<<3 | 2)
+	b.varint(uint64(len(msg)))
+	*b = append(*b, msg...)
+}
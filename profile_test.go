@@ -0,0 +1,106 @@
+package rd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestProfiler returns a Profiler whose clock advances by a millisecond every
+// time it's read.
+func newTestProfiler() *Profiler {
+	p := NewProfiler()
+	var now time.Time
+	p.now = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+	return p
+}
+
+func TestProfiler(t *testing.T) {
+	p := newTestProfiler()
+	b := NewBuilder([]Token{"x", "+", "(", "x", ")"}, Trace(p))
+	assert.True(t, benchExpr(b))
+	func() (ok bool) {
+		defer b.Enter("Extra").Exit(&ok)
+		b.Match("x")
+		b.Backtrack()
+		b.Skip()
+		return true
+	}()
+
+	// Expr
+	// ├─ Term
+	// └─ Term
+	//    └─ Expr
+	//       └─ Term
+	assert.Equal(t, []NonTermStats{
+		{NonTerm: "Expr", Calls: 2, Successes: 2, Tokens: 6, Time: 9 * time.Millisecond, SelfTime: 5 * time.Millisecond},
+		{NonTerm: "Term", Calls: 3, Successes: 3, Tokens: 5, Time: 6 * time.Millisecond, SelfTime: 4 * time.Millisecond},
+		{NonTerm: "Extra", Calls: 1, Successes: 1, Skips: 1, Backtracks: 1, Time: time.Millisecond, SelfTime: time.Millisecond},
+	}, p.Stats())
+
+	var buf bytes.Buffer
+	assert.Nil(t, p.WriteTable(&buf))
+	expected := `  calls  successes  failures  skips  memo hits  backtracks  tokens  self  time non-terminal
+      2          2         0      0          0           0       6   5ms   9ms Expr
+      3          3         0      0          0           0       5   4ms   6ms Term
+      1          1         0      1          0           1       0   1ms   1ms Extra
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestProfiler_WritePprof(t *testing.T) {
+	p := newTestProfiler()
+	b := NewBuilder([]Token{"x", "+", "(", "x", ")"}, Trace(p))
+	assert.True(t, benchExpr(b))
+
+	var buf bytes.Buffer
+	assert.Nil(t, p.WritePprof(&buf))
+	r, err := gzip.NewReader(&buf)
+	assert.Nil(t, err)
+	data, err := io.ReadAll(r)
+	assert.Nil(t, err)
+
+	// decode the Profile message's top-level fields
+	fields := map[uint64][][]byte{}
+	varint := func() uint64 {
+		var x uint64
+		for shift := 0; ; shift += 7 {
+			c := data[0]
+			data = data[1:]
+			x |= uint64(c&0x7f) << shift
+			if c < 0x80 {
+				return x
+			}
+		}
+	}
+	for len(data) > 0 {
+		key := varint()
+		if key&7 == 2 {
+			n := varint()
+			fields[key>>3] = append(fields[key>>3], data[:n])
+			data = data[n:]
+		} else {
+			fields[key>>3] = append(fields[key>>3], nil)
+			varint()
+		}
+	}
+
+	var strings []string
+	for _, s := range fields[6] {
+		strings = append(strings, string(s))
+	}
+	assert.Equal(t, []string{"", "calls", "count", "time", "nanoseconds", "Expr", "Term"}, strings)
+	assert.Len(t, fields[1], 2, "sample types")
+	assert.Len(t, fields[2], 4, "samples: Expr, Expr/Term, Expr/Term/Expr, Expr/Term/Expr/Term")
+	assert.Len(t, fields[4], 2, "locations")
+	assert.Len(t, fields[5], 2, "functions")
+	// Expr/Term/Expr/Term sample: locations (leaf first), then 1 call and 1ms
+	assert.Equal(t, []byte{0x0a, 4, 2, 1, 2, 1, 0x12, 4, 1, 0xc0, 0x84, 0x3d}, fields[2][3])
+}
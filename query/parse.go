@@ -0,0 +1,378 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shivamMg/rd"
+)
+
+// Error is an error in the selector text, returned by Compile.
+type Error struct {
+	Pos rd.Pos
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %s", e.Pos, e.Msg)
+}
+
+// token kinds other than punctuation, which is its own kind
+const (
+	kindName   = "name"
+	kindString = "string"
+	kindNumber = "number"
+	kindArg    = "argument"
+	// kindDescendant is whitespace between two compound selectors
+	kindDescendant = " "
+)
+
+type token struct {
+	kind     string
+	text     string // as it appears in the selector text
+	pos, end rd.Pos
+}
+
+func (t token) Kind() string {
+	return t.kind
+}
+
+func (t token) Pos() rd.Pos {
+	return t.pos
+}
+
+func (t token) End() rd.Pos {
+	return t.end
+}
+
+func (t token) String() string {
+	return t.text
+}
+
+func isNameStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+func isNamePart(r rune) bool {
+	return isNameStart(r) || unicode.IsDigit(r) || r == '-'
+}
+
+// endsCompound and startsCompound decide if whitespace between two tokens is a
+// descendant combinator.
+func endsCompound(kind string) bool {
+	switch kind {
+	case kindName, kindString, kindArg, "*", ")":
+		return true
+	}
+	return false
+}
+
+func startsCompound(kind string) bool {
+	switch kind {
+	case kindName, kindString, kindArg, "*", ":":
+		return true
+	}
+	return false
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	pos := rd.Pos{Offset: 0, Line: 1, Column: 1}
+	advance := func(n int) {
+		for _, r := range src[pos.Offset : pos.Offset+n] {
+			if r == '\n' {
+				pos.Line++
+				pos.Column = 1
+			} else {
+				pos.Column += utf8.RuneLen(r)
+			}
+		}
+		pos.Offset += n
+	}
+
+	var space *rd.Pos // start of whitespace before the next token
+	for pos.Offset < len(src) {
+		rest := src[pos.Offset:]
+		r, size := utf8.DecodeRuneInString(rest)
+		start := pos
+		var kind string
+		switch {
+		case unicode.IsSpace(r):
+			if space == nil {
+				space = &start
+			}
+			advance(size)
+			continue
+		case strings.ContainsRune(">+~,*:()", r):
+			kind = string(r)
+		case r == '"' || r == '\'':
+			end := strings.IndexRune(rest[size:], r)
+			if end <= 0 || strings.ContainsRune(rest[size:size+end], '\n') {
+				return nil, &Error{Pos: start, Msg: "unterminated or empty string"}
+			}
+			kind, size = kindString, size+end+1
+		case r == '$' || unicode.IsDigit(r):
+			kind = kindNumber
+			if r == '$' {
+				kind = kindArg
+			}
+			for _, r := range rest[size:] {
+				if !unicode.IsDigit(r) {
+					break
+				}
+				size += utf8.RuneLen(r)
+			}
+			if size == 1 && r == '$' {
+				return nil, &Error{Pos: start, Msg: "expected argument number after $"}
+			}
+		case isNameStart(r):
+			kind = kindName
+			for _, r := range rest[size:] {
+				if !isNamePart(r) {
+					break
+				}
+				size += utf8.RuneLen(r)
+			}
+		default:
+			return nil, &Error{Pos: start, Msg: fmt.Sprintf("invalid character %q", r)}
+		}
+		if space != nil && len(tokens) > 0 && endsCompound(tokens[len(tokens)-1].kind) && startsCompound(kind) {
+			tokens = append(tokens, token{kind: kindDescendant, text: " ", pos: *space, end: start})
+		}
+		space = nil
+		advance(size)
+		tokens = append(tokens, token{kind: kind, text: rest[:size], pos: start, end: pos})
+	}
+	return tokens, nil
+}
+
+// toError converts a parsing error from rd to an *Error.
+func toError(tokens []token, err error) *Error {
+	at := func(i int) (rd.Pos, string) {
+		if i < len(tokens) {
+			return tokens[i].pos, fmt.Sprintf("`%s`", tokens[i].text)
+		}
+		if len(tokens) == 0 {
+			return rd.Pos{Line: 1, Column: 1}, "end of selector"
+		}
+		return tokens[len(tokens)-1].end, "end of selector"
+	}
+
+	var pe *rd.ParsingError
+	if errors.As(err, &pe) {
+		pos, found := at(pe.Index)
+		var expected []string
+		for _, e := range pe.Expected {
+			switch kind := string(e.(rd.Label)); kind {
+			case kindName, kindString, kindNumber, kindArg:
+				expected = append(expected, kind)
+			case kindDescendant:
+				// only emitted where it's valid, so never worth suggesting
+			default:
+				expected = append(expected, "`"+kind+"`")
+			}
+		}
+		msg := "unexpected " + found
+		if n := len(expected); n > 1 {
+			msg = fmt.Sprintf("expected %s or %s, found %s", strings.Join(expected[:n-1], ", "), expected[n-1], found)
+		} else if n == 1 {
+			msg = fmt.Sprintf("expected %s, found %s", expected[0], found)
+		}
+		return &Error{Pos: pos, Msg: msg}
+	}
+	var nce *rd.NotConsumedError
+	if errors.As(err, &nce) {
+		pos, found := at(nce.Index)
+		return &Error{Pos: pos, Msg: "unexpected " + found}
+	}
+	return &Error{Msg: err.Error()}
+}
+
+// selector parsing functions. Selectors in arguments of pseudo-classes are
+// relative: they may start with a combinator, which is only valid inside :has,
+// and is checked while building.
+
+func selectorList(b *rd.BuilderOf[token], relative bool) (ok bool) {
+	defer b.Enter("selectorList").Exit(&ok)
+
+	if !selector(b, relative) {
+		return false
+	}
+	for b.MatchKind(",") {
+		if !selector(b, relative) {
+			return false
+		}
+	}
+	return true
+}
+
+func selector(b *rd.BuilderOf[token], relative bool) (ok bool) {
+	defer b.Enter("selector").Exit(&ok)
+
+	if relative {
+		combinator(b)
+	}
+	if !compound(b) {
+		return false
+	}
+	for combinator(b) {
+		if !compound(b) {
+			return false
+		}
+	}
+	return true
+}
+
+func combinator(b *rd.BuilderOf[token]) bool {
+	return b.MatchKind(kindDescendant) || b.MatchKind(">") || b.MatchKind("+") || b.MatchKind("~")
+}
+
+func compound(b *rd.BuilderOf[token]) (ok bool) {
+	defer b.Enter("compound").Exit(&ok)
+
+	typed := b.MatchKind(kindName) || b.MatchKind(kindString) || b.MatchKind(kindArg) || b.MatchKind("*")
+	if !pseudo(b) {
+		return typed
+	}
+	for pseudo(b) {
+	}
+	return true
+}
+
+func pseudo(b *rd.BuilderOf[token]) (ok bool) {
+	defer b.Enter("pseudo").Exit(&ok)
+
+	if !(b.MatchKind(":") && b.MatchKind(kindName)) {
+		return false
+	}
+	if !b.MatchKind("(") {
+		return true
+	}
+	return (b.MatchKind(kindNumber) || selectorList(b, true)) && b.MatchKind(")")
+}
+
+// builder builds selectors from parse trees.
+type builder struct {
+	args []interface{}
+}
+
+func (bd *builder) selectorList(t *rd.TreeOf[token], relative bool) (sels []chain, err error) {
+	for _, st := range t.Subtrees {
+		if _, ok := st.Token(); ok {
+			continue // ","
+		}
+		sel, err := bd.selector(st, relative)
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	return sels, nil
+}
+
+func (bd *builder) selector(t *rd.TreeOf[token], relative bool) (sel chain, err error) {
+	subtrees := t.Subtrees
+	comb := ""
+	if first, ok := subtrees[0].Token(); ok {
+		if !relative {
+			return nil, &Error{Pos: first.pos, Msg: fmt.Sprintf("unexpected `%s` at the start of a :not selector", first.text)}
+		}
+		comb, subtrees = first.text, subtrees[1:]
+	}
+	if relative {
+		if comb == "" {
+			comb = kindDescendant
+		}
+		sel = append(sel, step{tests: []test{isScope}})
+	}
+	for _, st := range subtrees {
+		if tok, ok := st.Token(); ok {
+			comb = tok.text
+			continue
+		}
+		tests, err := bd.compound(st)
+		if err != nil {
+			return nil, err
+		}
+		sel = append(sel, step{combinator: comb, tests: tests})
+	}
+	return sel, nil
+}
+
+func (bd *builder) compound(t *rd.TreeOf[token]) (tests []test, err error) {
+	for _, st := range t.Subtrees {
+		tok, ok := st.Token()
+		if !ok {
+			test, err := bd.pseudo(st)
+			if err != nil {
+				return nil, err
+			}
+			tests = append(tests, test)
+			continue
+		}
+		switch tok.kind {
+		case kindName:
+			tests = append(tests, symbolName(tok.text))
+		case kindString:
+			tests = append(tests, symbolName(tok.text[1:len(tok.text)-1]))
+		case kindArg:
+			i, _ := strconv.Atoi(tok.text[1:])
+			if i < 1 || i > len(bd.args) {
+				return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("no argument for %s", tok.text)}
+			}
+			tests = append(tests, symbolEqual(bd.args[i-1]))
+		}
+	}
+	return tests, nil
+}
+
+func (bd *builder) pseudo(t *rd.TreeOf[token]) (test, error) {
+	name, _ := t.Subtrees[1].Token()
+	var arg *rd.TreeOf[token]
+	if len(t.Subtrees) > 2 {
+		arg = t.Subtrees[3]
+	}
+	fail := func(format string, a ...interface{}) (test, error) {
+		return nil, &Error{Pos: name.pos, Msg: fmt.Sprintf(":%s ", name.text) + fmt.Sprintf(format, a...)}
+	}
+
+	switch name.text {
+	case "root", "leaf", "first-child", "last-child", "only-child":
+		if arg != nil {
+			return fail("takes no argument")
+		}
+		return simplePseudos[name.text], nil
+	case "nth-child", "nth-last-child":
+		n := 0
+		if arg != nil {
+			if tok, ok := arg.Token(); ok {
+				n, _ = strconv.Atoi(tok.text)
+			}
+		}
+		if n < 1 {
+			return fail("takes a number from 1")
+		}
+		if name.text == "nth-child" {
+			return nthChild(n), nil
+		}
+		return nthLastChild(n), nil
+	case "has", "not":
+		if arg == nil || len(arg.Subtrees) == 0 {
+			return fail("takes a selector")
+		}
+		relative := name.text == "has"
+		sels, err := bd.selectorList(arg, relative)
+		if err != nil {
+			return nil, err
+		}
+		if relative {
+			return has(sels), nil
+		}
+		return not(sels), nil
+	}
+	return fail("is unknown")
+}
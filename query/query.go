@@ -0,0 +1,269 @@
+// Package query selects nodes of parse trees using selectors, a small language
+// modeled after CSS selectors. Selectors are compiled once, and can be used to
+// query any number of trees.
+//
+// A selector is made of compound selectors, which test nodes, joined by
+// combinators, which relate them. A compound selector is a type test, optionally
+// followed by pseudo-classes. Pseudo-classes can also be used on their own, in
+// which case they test any node.
+//
+//	Block          nodes whose symbol, printed by fmt.Sprint, is Block
+//	":="           the same, for symbols that aren't names
+//	$1             nodes whose symbol is equal to the first argument passed to
+//	               Compile, ex. a token
+//	*              any node
+//
+//	A B            B that is a descendant of A
+//	A > B          B that is a subtree of A
+//	A + B          B that is the sibling right after A
+//	A ~ B          B that is a sibling after A
+//	S1, S2         nodes selected by S1 or S2
+//
+//	:root                  the tree being queried
+//	:leaf                  nodes without subtrees
+//	:first-child           nodes that are the first subtree of their parent
+//	:last-child            nodes that are the last subtree of their parent
+//	:only-child            nodes that are the only subtree of their parent
+//	:nth-child(n)          nodes that are the nth subtree of their parent,
+//	                       counting from 1
+//	:nth-last-child(n)     the same, counting from the last subtree
+//	:has(S1, S2)           nodes for which S1 or S2 selects a node. They are
+//	                       relative to the node: they can start with a combinator,
+//	                       and descendant is implied if they don't
+//	:not(S1, S2)           nodes not selected by S1 or S2
+//
+// ex. with the parse trees of examples/pl0,
+//
+//	Statement:has(> call:first-child)  statements calling a procedure
+//	Block > var ~ Ident                identifiers of variables (and procedures)
+//	                                   declared by blocks
+//	Expression Ident:not(:has(x))      identifiers other than x in expressions
+package query
+
+import (
+	"fmt"
+
+	"github.com/shivamMg/rd"
+)
+
+// Selector is a compiled selector. It's safe for concurrent use.
+type Selector struct {
+	src       string
+	selectors []chain
+}
+
+// chain is a list of steps, matched from the last one to the first one.
+type chain []step
+
+type step struct {
+	// combinator relates the node matched by the previous step to the node
+	// matched by this step. It's empty for the first step.
+	combinator string
+	tests      []test
+}
+
+// test tests the node c points to. scope is the node a :has selector is
+// relative to, or nil.
+type test func(c *rd.Cursor, scope *rd.Tree) bool
+
+// Compile compiles a selector. args are the values of the placeholders $1, $2,
+// ... in src.
+func Compile(src string, args ...interface{}) (*Selector, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	b := rd.NewBuilderOf(tokens)
+	selectorList(b, false)
+	if err := b.Err(); err != nil {
+		return nil, toError(tokens, err)
+	}
+	bd := &builder{args: args}
+	selectors, err := bd.selectorList(b.ParseTree(), false)
+	if err != nil {
+		return nil, err
+	}
+	return &Selector{src: src, selectors: selectors}, nil
+}
+
+// MustCompile is like Compile but panics if the selector can't be compiled. It's
+// helpful for selectors declared as global variables.
+func MustCompile(src string, args ...interface{}) *Selector {
+	s, err := Compile(src, args...)
+	if err != nil {
+		panic("query: " + err.Error())
+	}
+	return s
+}
+
+// String returns the source text of s.
+func (s *Selector) String() string {
+	return s.src
+}
+
+// Select returns the nodes in t (including t) selected by s, in pre-order.
+func (s *Selector) Select(t *rd.Tree) (nodes []*rd.Tree) {
+	find(t.Cursor(), func(c *rd.Cursor) bool {
+		if s.Match(c) {
+			nodes = append(nodes, c.Node())
+		}
+		return false
+	})
+	return nodes
+}
+
+// First returns the first node in t (including t), in pre-order, selected by s.
+// Returns nil if there's none.
+func (s *Selector) First(t *rd.Tree) (node *rd.Tree) {
+	find(t.Cursor(), func(c *rd.Cursor) bool {
+		if s.Match(c) {
+			node = c.Node()
+			return true
+		}
+		return false
+	})
+	return node
+}
+
+// Match reports whether s selects the node c points to. The cursor's root is
+// the tree being queried, ex. for :root.
+func (s *Selector) Match(c *rd.Cursor) bool {
+	return matchAny(s.selectors, c, nil)
+}
+
+func matchAny(selectors []chain, c *rd.Cursor, scope *rd.Tree) bool {
+	for _, sel := range selectors {
+		if sel.match(c, len(sel)-1, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether the node c points to matches step i, and the nodes it's
+// related to match the steps before it. It doesn't move c.
+func (sel chain) match(c *rd.Cursor, i int, scope *rd.Tree) bool {
+	for _, test := range sel[i].tests {
+		if !test(c, scope) {
+			return false
+		}
+	}
+	if i == 0 {
+		return true
+	}
+	d := c.Copy()
+	switch sel[i].combinator {
+	case ">":
+		return d.GotoParent() && sel.match(d, i-1, scope)
+	case "+":
+		return d.GotoPrevSibling() && sel.match(d, i-1, scope)
+	case "~":
+		for d.GotoPrevSibling() {
+			if sel.match(d, i-1, scope) {
+				return true
+			}
+		}
+	default: // descendant
+		for d.GotoParent() {
+			if sel.match(d, i-1, scope) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// find reports whether f returns true for the node c points to or one of its
+// descendants, calling f for them in pre-order until it does. c is moved to
+// these nodes, and back.
+func find(c *rd.Cursor, f func(c *rd.Cursor) bool) bool {
+	if f(c) {
+		return true
+	}
+	if !c.GotoFirstChild() {
+		return false
+	}
+	defer c.GotoParent()
+	for {
+		if find(c, f) {
+			return true
+		}
+		if !c.GotoNextSibling() {
+			return false
+		}
+	}
+}
+
+func symbolName(name string) test {
+	return func(c *rd.Cursor, _ *rd.Tree) bool {
+		return fmt.Sprint(c.Node().Symbol) == name
+	}
+}
+
+func symbolEqual(symbol interface{}) test {
+	return func(c *rd.Cursor, _ *rd.Tree) bool {
+		return c.Node().Symbol == symbol
+	}
+}
+
+func isScope(c *rd.Cursor, scope *rd.Tree) bool {
+	return c.Node() == scope
+}
+
+// siblings returns the number of subtrees of the parent of the node c points to,
+// or 0 for the root.
+func siblings(c *rd.Cursor) int {
+	if parent := c.Parent(); parent != nil {
+		return len(parent.Subtrees)
+	}
+	return 0
+}
+
+var simplePseudos = map[string]test{
+	"root": func(c *rd.Cursor, _ *rd.Tree) bool {
+		return c.Depth() == 0
+	},
+	"leaf": func(c *rd.Cursor, _ *rd.Tree) bool {
+		return len(c.Node().Subtrees) == 0
+	},
+	"first-child": nthChild(1),
+	"last-child":  nthLastChild(1),
+	"only-child": func(c *rd.Cursor, _ *rd.Tree) bool {
+		return siblings(c) == 1
+	},
+}
+
+func nthChild(n int) test {
+	return func(c *rd.Cursor, _ *rd.Tree) bool {
+		return c.Index() == n-1
+	}
+}
+
+func nthLastChild(n int) test {
+	return func(c *rd.Cursor, _ *rd.Tree) bool {
+		return c.Depth() > 0 && c.Index() == siblings(c)-n
+	}
+}
+
+// has tests if a selector relative to the node (its scope) selects its
+// descendants or following siblings, or their descendants.
+func has(selectors []chain) test {
+	return func(c *rd.Cursor, _ *rd.Tree) bool {
+		scope := c.Node()
+		d := c.Copy()
+		for {
+			if find(d, func(d *rd.Cursor) bool { return matchAny(selectors, d, scope) }) {
+				return true
+			}
+			if !d.GotoNextSibling() {
+				return false
+			}
+		}
+	}
+}
+
+func not(selectors []chain) test {
+	return func(c *rd.Cursor, scope *rd.Tree) bool {
+		return !matchAny(selectors, c, scope)
+	}
+}
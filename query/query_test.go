@@ -0,0 +1,123 @@
+package query
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shivamMg/rd"
+	"github.com/stretchr/testify/assert"
+)
+
+// testTree returns the PL/0-like parse tree of:
+//
+//	var x, y;
+//	begin call p; x := y end
+func testTree() *rd.Tree {
+	ident := func(name string) *rd.Tree {
+		return rd.NewTree("Ident", rd.NewTree(name))
+	}
+	return rd.NewTree("Block",
+		rd.NewTree("var"), ident("x"), rd.NewTree(","), ident("y"), rd.NewTree(";"),
+		rd.NewTree("Statement",
+			rd.NewTree("begin"),
+			rd.NewTree("Statement", rd.NewTree("call"), ident("p")),
+			rd.NewTree(";"),
+			rd.NewTree("Statement", ident("x"), rd.NewTree(":="), ident("y")),
+			rd.NewTree("end"),
+		),
+	)
+}
+
+// describe describes nodes by their symbols, followed by their tokens for
+// non-leaves.
+func describe(nodes []*rd.Tree) (s []string) {
+	for _, n := range nodes {
+		if len(n.Subtrees) == 0 {
+			s = append(s, fmt.Sprint(n.Symbol))
+		} else {
+			s = append(s, fmt.Sprint(n.Symbol, n.Tokens()))
+		}
+	}
+	return s
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		selector string
+		expected []string
+	}{
+		{"Ident", []string{"Ident[x]", "Ident[y]", "Ident[p]", "Ident[x]", "Ident[y]"}},
+		{"Block > Ident", []string{"Ident[x]", "Ident[y]"}},
+		{"Block>Ident", []string{"Ident[x]", "Ident[y]"}},
+		{"Statement Ident", []string{"Ident[p]", "Ident[x]", "Ident[y]"}},
+		{"var ~ Ident", []string{"Ident[x]", "Ident[y]"}},
+		{"var + Ident", []string{"Ident[x]"}},
+		{"Block var ~ Ident > *", []string{"x", "y"}},
+		{"Statement:has(> call:first-child)", []string{"Statement[call p]"}},
+		{"Statement:has(+ end)", []string{"Statement[x := y]"}},
+		{"Statement:has(Ident y)", []string{"Statement[begin call p ; x := y end]", "Statement[x := y]"}},
+		{"Statement > Ident:first-child", []string{"Ident[x]"}},
+		{"Statement Statement:nth-child(4)", []string{"Statement[x := y]"}},
+		{"Statement > :nth-last-child(2)", []string{"call", "Statement[x := y]", ":="}},
+		{"Ident:not(:has(x), :has(y))", []string{"Ident[p]"}},
+		{`":="`, []string{":="}},
+		{"':=' + *", []string{"Ident[y]"}},
+		{":root", []string{"Block[var x , y ; begin call p ; x := y end]"}},
+		{"Block > :leaf", []string{"var", ",", ";"}},
+		{"* > :only-child", []string{"x", "y", "p", "x", "y"}},
+		{":last-child:leaf", []string{"x", "y", "p", "x", "y", "end"}},
+		{"end, call", []string{"call", "end"}},
+		{"Expression", nil},
+	}
+	tree := testTree()
+	for _, test := range tests {
+		s, err := Compile(test.selector)
+		if assert.NoError(t, err, test.selector) {
+			assert.Equal(t, test.expected, describe(s.Select(tree)), test.selector)
+		}
+	}
+}
+
+func TestSelector(t *testing.T) {
+	tree := rd.NewTree("Number", rd.NewTree("1"), rd.NewTree(1))
+	s := MustCompile("Number > $1", 1)
+	assert.Equal(t, []*rd.Tree{tree.Subtrees[1]}, s.Select(tree))
+	assert.Equal(t, tree.Subtrees[1], s.First(tree))
+	assert.Len(t, MustCompile("Number > '1'").Select(tree), 2)
+	assert.Equal(t, "Number > $1", s.String())
+
+	c := tree.Cursor()
+	assert.False(t, s.Match(c))
+	c.GotoLastChild()
+	assert.True(t, s.Match(c))
+
+	assert.Nil(t, MustCompile("Ident").First(tree))
+	assert.PanicsWithValue(t, "query: 1:1: no argument for $1", func() { MustCompile("$1") })
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		selector string
+		err      string
+	}{
+		{"", "1:1: expected name, string, argument, `*` or `:`, found end of selector"},
+		{"Block >", "1:8: expected name, string, argument, `*` or `:`, found end of selector"},
+		{"Block > > Ident", "1:9: expected name, string, argument, `*` or `:`, found `>`"},
+		{"> Ident", "1:1: expected name, string, argument, `*` or `:`, found `>`"},
+		{":has(> Ident, :not(> Ident))", "1:20: unexpected `>` at the start of a :not selector"},
+		{"Ident:", "1:6: unexpected `:`"},
+		{"Ident:second-child", "1:7: :second-child is unknown"},
+		{":first-child(1)", "1:2: :first-child takes no argument"},
+		{":nth-child(0)", "1:2: :nth-child takes a number from 1"},
+		{":nth-child", "1:2: :nth-child takes a number from 1"},
+		{":has(1)", "1:2: :has takes a selector"},
+		{"$0", "1:1: no argument for $0"},
+		{"$", "1:1: expected argument number after $"},
+		{"Ident.", "1:6: invalid character '.'"},
+		{`"Ident`, "1:1: unterminated or empty string"},
+	}
+	for _, test := range tests {
+		_, err := Compile(test.selector)
+		assert.EqualError(t, err, test.err, test.selector)
+	}
+}
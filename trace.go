@@ -0,0 +1,371 @@
+package rd
+
+import (
+	"fmt"
+)
+
+// Tracer is notified by a Builder as parsing progresses. Tracers are added using
+// the Trace option. Tokens are passed as they are, boxed in Token for BuilderOf.
+//
+// Token indexes in events are indexes in the tokens passed to the Builder.
+// Matches done by Match, MatchFunc and MatchKind are notified as Match events
+// only, and not as the Next and Add events they involve.
+type Tracer interface {
+	Enter(e EnterEvent)
+	Exit(e ExitEvent)
+	Match(e MatchEvent)
+	Next(e NextEvent)
+	Peek(e PeekEvent)
+	Backtrack(e BacktrackEvent)
+	Add(e AddEvent)
+	Alternative(e AlternativeEvent)
+}
+
+// Trace adds t to the tracers notified by a Builder.
+func Trace(t Tracer) Option {
+	return func(o *options) {
+		o.tracers = append(o.tracers, t)
+	}
+}
+
+// EnterEvent is a non-terminal being entered. Index is the index of the next
+// token, except for infix and postfix operators entered by Operators, which are
+// entered once their left operand has been parsed: Index is then the index their
+// left operand started at, and the left operand's events precede it.
+type EnterEvent struct {
+	NonTerm interface{}
+	Index   int
+}
+
+// ExitEvent is a non-terminal exiting. Tokens in [Start, End) were consumed by
+// it, so End equals Start unless Result is true.
+type ExitEvent struct {
+	NonTerm    interface{}
+	Start, End int
+	Result     bool
+	// Skip is set if Skip was called. The non-terminal isn't added to the parse
+	// tree, and no tokens are consumed.
+	Skip bool
+	// Recovered is set if the non-terminal recovered from a parsing error (see
+	// Sync). Result is then true.
+	Recovered bool
+	// MemoHit is set if the result was replayed from memory (see Memo).
+	MemoHit bool
+}
+
+// MatchEvent is a match attempt by Match, MatchFunc or MatchKind. Want is the
+// token matched against, or a Label. Token is the token at Index, unless there
+// were no tokens left, in which case NoTokens is true.
+type MatchEvent struct {
+	Index    int
+	Token    Token
+	NoTokens bool
+	Want     Token
+	OK       bool
+}
+
+// NextEvent is a call to Next. Token is the token at Index, unless there were no
+// tokens left, in which case OK is false.
+type NextEvent struct {
+	Index int
+	Token Token
+	OK    bool
+}
+
+// PeekEvent is a call to Peek, or to its convenience functions (ex. Check).
+// Token is the token at Index, unless Index is out of range, in which case OK is
+// false.
+type PeekEvent struct {
+	Index int
+	Token Token
+	OK    bool
+}
+
+// BacktrackEvent is a call to Backtrack or Reset inside NonTerm, moving the index
+// of the next token from From to To.
+type BacktrackEvent struct {
+	NonTerm  interface{}
+	From, To int
+	// GrowSeed is set if it's a left-recursive non-terminal being parsed again
+	// to grow its result (see Memo), instead of a call to Backtrack or Reset.
+	GrowSeed bool
+}
+
+// AddEvent is a call to Add. Index is the index of the current token.
+type AddEvent struct {
+	Index int
+	Token Token
+}
+
+// AlternativeEvent is a call to Alternative: alternative Alt of NonTerm was tried,
+// and OK is its result.
+type AlternativeEvent struct {
+	NonTerm interface{}
+	Alt     string
+	OK      bool
+}
+
+// NopTracer is a Tracer that does nothing. It can be embedded in types that only
+// need some of Tracer's methods.
+type NopTracer struct{}
+
+func (NopTracer) Enter(EnterEvent)             {}
+func (NopTracer) Exit(ExitEvent)               {}
+func (NopTracer) Match(MatchEvent)             {}
+func (NopTracer) Next(NextEvent)               {}
+func (NopTracer) Peek(PeekEvent)               {}
+func (NopTracer) Backtrack(BacktrackEvent)     {}
+func (NopTracer) Add(AddEvent)                 {}
+func (NopTracer) Alternative(AlternativeEvent) {}
+
+// NoDebugTree disables the debug tree, which otherwise costs allocations on every
+// Enter and match. DebugTree then returns nil. Tracers added using Trace are still
+// notified.
+func NoDebugTree() Option {
+	return func(o *options) {
+		o.debug, o.debugLast = debugOff, 0
+	}
+}
+
+// DebugFailures keeps only the failing path in the debug tree: non-terminals that
+// succeed are kept without their subtrees. Their events aren't allocated, so
+// parsing valid input costs about as little as with NoDebugTree.
+func DebugFailures() Option {
+	return func(o *options) {
+		o.debug, o.debugLast = debugFailures, 0
+	}
+}
+
+// DebugLast keeps only the last n events (non-terminal entries and exits, and
+// matches) in the debug tree. Non-terminals whose entry is among the dropped
+// events hold the nodes before them, under a root labelled "<last n events>".
+// Non-terminals still in progress have no result. Unlike the full debug tree, it's
+// available before the root non-terminal exits, ex. to inspect a parser that
+// panicked.
+func DebugLast(n int) Option {
+	if n <= 0 {
+		panic("DebugLast must keep at least one event")
+	}
+	return func(o *options) {
+		o.debug, o.debugLast = debugLast, n
+	}
+}
+
+type debugMode int
+
+const (
+	debugAll debugMode = iota
+	debugOff
+	debugFailures
+	debugLast
+)
+
+// DebugTracer is a Tracer that builds a debug tree (see DebugTree). A Builder
+// uses one to build the tree returned by its DebugTree method.
+type DebugTracer struct {
+	NopTracer
+	stack debugStack
+	tree  *DebugTree
+
+	// In modes other than debugAll, events are logged instead, and the tree is
+	// built from the log when it's needed.
+	mode debugMode
+	log  []debugEvent
+	// enters has indexes in log of Enter events of non-terminals in progress
+	// (debugFailures)
+	enters []int
+	// log is a ring buffer: next is the index the next event is logged at, and
+	// logged is the number of events in it (debugLast)
+	next, logged int
+}
+
+// NewDebugTracer returns a new DebugTracer.
+func NewDebugTracer() *DebugTracer {
+	return &DebugTracer{stack: debugStack{}}
+}
+
+func newDebugTracer(o options) *DebugTracer {
+	switch o.debug {
+	case debugOff:
+		return nil
+	case debugLast:
+		return &DebugTracer{mode: debugLast, log: make([]debugEvent, o.debugLast)}
+	}
+	t := NewDebugTracer()
+	t.mode = o.debug
+	return t
+}
+
+// Tree returns the debug tree. It's set after the root non-terminal exits.
+// Returns nil otherwise. If only the last events are kept, it's built from them
+// whenever called, and is nil only if there are none.
+func (t *DebugTracer) Tree() *DebugTree {
+	if t.mode != debugLast {
+		return t.tree
+	}
+	if t.logged == 0 {
+		return nil
+	}
+	events := t.log[:t.logged]
+	if t.logged == len(t.log) {
+		events = append(append([]debugEvent{}, t.log[t.next:]...), t.log[:t.next]...)
+	}
+	root := &DebugTree{Event: fmt.Sprintf("<last %d events>", t.logged)}
+	return buildDebugTree(events, root)
+}
+
+func (t *DebugTracer) Enter(e EnterEvent) {
+	switch t.mode {
+	case debugAll:
+		t.stack.push(&DebugTree{Event: e})
+	case debugFailures:
+		t.enters = append(t.enters, len(t.log))
+		t.log = append(t.log, debugEvent{kind: enterKind, enter: e})
+	case debugLast:
+		t.record(debugEvent{kind: enterKind, enter: e})
+	}
+}
+
+func (t *DebugTracer) Exit(e ExitEvent) {
+	switch t.mode {
+	case debugAll:
+		dt := t.stack.pop()
+		dt.Event = e
+		if t.stack.isEmpty() {
+			t.tree = dt
+		} else {
+			t.stack.peek().add(dt)
+		}
+	case debugFailures:
+		i := t.enters[len(t.enters)-1]
+		t.enters = t.enters[:len(t.enters)-1]
+		if e.Result && !e.Recovered {
+			t.log = append(t.log[:i], debugEvent{kind: leafKind, exit: e})
+		} else {
+			t.log = append(t.log, debugEvent{kind: exitKind, exit: e})
+		}
+		if len(t.enters) == 0 {
+			t.tree = buildDebugTree(t.log, &DebugTree{}).Subtrees[0]
+			t.log = nil
+		}
+	case debugLast:
+		t.record(debugEvent{kind: exitKind, exit: e})
+	}
+}
+
+func (t *DebugTracer) Match(e MatchEvent) {
+	switch t.mode {
+	case debugAll:
+		t.stack.peek().add(&DebugTree{Event: e})
+	case debugFailures:
+		t.log = append(t.log, debugEvent{kind: matchKind, match: e})
+	case debugLast:
+		t.record(debugEvent{kind: matchKind, match: e})
+	}
+}
+
+func (t *DebugTracer) Backtrack(e BacktrackEvent) {
+	if !e.GrowSeed {
+		return
+	}
+	switch t.mode {
+	case debugAll:
+		t.stack.peek().add(&DebugTree{Event: e})
+	case debugFailures:
+		t.log = append(t.log, debugEvent{kind: growSeedKind, backtrack: e})
+	case debugLast:
+		t.record(debugEvent{kind: growSeedKind, backtrack: e})
+	}
+}
+
+func (t *DebugTracer) record(e debugEvent) {
+	t.log[t.next] = e
+	t.next = (t.next + 1) % len(t.log)
+	if t.logged < len(t.log) {
+		t.logged++
+	}
+}
+
+// debugEvent is an event logged by DebugTracer. It isn't boxed in an interface,
+// so that logging it doesn't allocate.
+type debugEvent struct {
+	kind      int
+	enter     EnterEvent
+	exit      ExitEvent
+	match     MatchEvent
+	backtrack BacktrackEvent
+}
+
+const (
+	enterKind = iota
+	exitKind
+	// leafKind is an exit whose Enter event and subtrees were discarded
+	leafKind
+	matchKind
+	growSeedKind
+)
+
+func (e debugEvent) event() interface{} {
+	switch e.kind {
+	case enterKind:
+		return e.enter
+	case exitKind, leafKind:
+		return e.exit
+	case matchKind:
+		return e.match
+	}
+	return e.backtrack
+}
+
+// buildDebugTree adds nodes for events under root and returns it. Exits whose
+// Enter event isn't in events hold the nodes added before them.
+func buildDebugTree(events []debugEvent, root *DebugTree) *DebugTree {
+	stack := debugStack{root}
+	for _, e := range events {
+		switch e.kind {
+		case enterKind:
+			stack.push(&DebugTree{Event: e.enter})
+		case exitKind:
+			if len(stack) > 1 {
+				dt := stack.pop()
+				dt.Event = e.exit
+				stack.peek().add(dt)
+			} else {
+				root.Subtrees = []*DebugTree{{Event: e.exit, Subtrees: root.Subtrees}}
+			}
+		default:
+			stack.peek().add(&DebugTree{Event: e.event()})
+		}
+	}
+	for len(stack) > 1 {
+		dt := stack.pop()
+		stack.peek().add(dt)
+	}
+	return root
+}
+
+// eventString returns how event is displayed in debug trees.
+func eventString(event interface{}) string {
+	switch e := event.(type) {
+	case EnterEvent:
+		return fmt.Sprint(e.NonTerm)
+	case ExitEvent:
+		if e.Recovered {
+			return fmt.Sprint(e.NonTerm, "(recovered)")
+		}
+		return fmt.Sprintf("%v(%t)", e.NonTerm, e.Result)
+	case MatchEvent:
+		switch {
+		case e.NoTokens:
+			return fmt.Sprint("<no tokens left> ≠ ", e.Want)
+		case e.OK:
+			return fmt.Sprint(e.Token, " = ", e.Want)
+		}
+		return fmt.Sprint(e.Token, " ≠ ", e.Want)
+	case BacktrackEvent:
+		if e.GrowSeed {
+			return "<grow seed>"
+		}
+	}
+	return fmt.Sprint(event)
+}
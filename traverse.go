@@ -0,0 +1,304 @@
+package rd
+
+// WalkControl is returned by functions called by Walk, to control the walk.
+type WalkControl int
+
+const (
+	// Continue continues the walk.
+	Continue WalkControl = iota
+	// SkipChildren skips the subtrees of the current node. It's the same as
+	// Continue if returned after the subtrees were walked.
+	SkipChildren
+	// Stop stops the walk.
+	Stop
+)
+
+// Walk walks t depth-first. pre is called for a node before its subtrees are
+// walked (pre-order), and post after (post-order). post isn't called for nodes
+// whose pre returned Stop. Either can be nil. Walk returns false if the walk was
+// stopped.
+func (t *TreeOf[T]) Walk(pre, post func(t *TreeOf[T]) WalkControl) bool {
+	ctrl := Continue
+	if pre != nil {
+		ctrl = pre(t)
+	}
+	switch ctrl {
+	case Stop:
+		return false
+	case Continue:
+		for _, st := range t.Subtrees {
+			if !st.Walk(pre, post) {
+				return false
+			}
+		}
+	}
+	return post == nil || post(t) != Stop
+}
+
+// Visitor visits nodes of a tree walked by Accept.
+type Visitor[T any] interface {
+	// Visit is called for a node before its subtrees are visited.
+	Visit(t *TreeOf[T]) WalkControl
+	// Leave is called for a node after its subtrees are visited, or skipped.
+	Leave(t *TreeOf[T]) WalkControl
+}
+
+// Accept walks t with v (see Walk). It returns false if the walk was stopped.
+func (t *TreeOf[T]) Accept(v Visitor[T]) bool {
+	return t.Walk(v.Visit, v.Leave)
+}
+
+// SymbolVisitor is a Visitor that dispatches nodes to functions by their symbol.
+// Nodes whose symbol doesn't have a function are visited with Continue.
+//
+// ex.
+//
+//	tree.Accept(rd.SymbolVisitor[rd.Token]{
+//		OnVisit: map[interface{}]func(t *rd.Tree) rd.WalkControl{
+//			"Ident": func(t *rd.Tree) rd.WalkControl { ... },
+//		},
+//	})
+type SymbolVisitor[T any] struct {
+	OnVisit map[interface{}]func(t *TreeOf[T]) WalkControl
+	OnLeave map[interface{}]func(t *TreeOf[T]) WalkControl
+}
+
+func (v SymbolVisitor[T]) Visit(t *TreeOf[T]) WalkControl {
+	return dispatch(v.OnVisit, t)
+}
+
+func (v SymbolVisitor[T]) Leave(t *TreeOf[T]) WalkControl {
+	return dispatch(v.OnLeave, t)
+}
+
+func dispatch[T any](fs map[interface{}]func(t *TreeOf[T]) WalkControl, t *TreeOf[T]) WalkControl {
+	if f, ok := fs[t.Symbol]; ok {
+		return f(t)
+	}
+	return Continue
+}
+
+// Find returns the first node in t (including t), in pre-order, for which match
+// returns true. Returns nil if there's none.
+func (t *TreeOf[T]) Find(match func(t *TreeOf[T]) bool) (found *TreeOf[T]) {
+	t.Walk(func(t *TreeOf[T]) WalkControl {
+		if match(t) {
+			found = t
+			return Stop
+		}
+		return Continue
+	}, nil)
+	return found
+}
+
+// FindAll returns the nodes in t (including t), in pre-order, for which match
+// returns true.
+func (t *TreeOf[T]) FindAll(match func(t *TreeOf[T]) bool) (found []*TreeOf[T]) {
+	t.Walk(func(t *TreeOf[T]) WalkControl {
+		if match(t) {
+			found = append(found, t)
+		}
+		return Continue
+	}, nil)
+	return found
+}
+
+// FindSymbol returns the first node in t (including t), in pre-order, whose symbol
+// is symbol. Returns nil if there's none.
+func (t *TreeOf[T]) FindSymbol(symbol interface{}) *TreeOf[T] {
+	return t.Find(func(t *TreeOf[T]) bool {
+		return t.Symbol == symbol
+	})
+}
+
+// FindAllSymbol returns the nodes in t (including t), in pre-order, whose symbol is
+// symbol.
+func (t *TreeOf[T]) FindAllSymbol(symbol interface{}) []*TreeOf[T] {
+	return t.FindAll(func(t *TreeOf[T]) bool {
+		return t.Symbol == symbol
+	})
+}
+
+// Leaves returns the nodes in t without subtrees, from left to right.
+func (t *TreeOf[T]) Leaves() []*TreeOf[T] {
+	return t.FindAll(func(t *TreeOf[T]) bool {
+		return len(t.Subtrees) == 0
+	})
+}
+
+// Tokens returns the symbols of Leaves that are tokens of type T. For a parse
+// tree, these are the tokens it matched (and added).
+func (t *TreeOf[T]) Tokens() (tokens []T) {
+	for _, leaf := range t.Leaves() {
+		if token, ok := leaf.Token(); ok {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// AtDepth returns the nodes at depth in t, from left to right. t is at depth 0,
+// its subtrees at depth 1, and so on.
+func (t *TreeOf[T]) AtDepth(depth int) []*TreeOf[T] {
+	if depth == 0 {
+		return []*TreeOf[T]{t}
+	}
+	var nodes []*TreeOf[T]
+	for _, st := range t.Subtrees {
+		nodes = append(nodes, st.AtDepth(depth-1)...)
+	}
+	return nodes
+}
+
+// Height returns the depth of the deepest node in t. It's 0 if t has no subtrees.
+func (t *TreeOf[T]) Height() int {
+	height := 0
+	for _, st := range t.Subtrees {
+		if h := st.Height() + 1; h > height {
+			height = h
+		}
+	}
+	return height
+}
+
+// Cursor is a CursorOf for Tree.
+type Cursor = CursorOf[Token]
+
+// CursorOf points to a node in a tree, and moves to its parent, children and
+// siblings. It remembers the path from the tree's root, since nodes don't point
+// to their parents. Methods that move the cursor return false, without moving
+// it, if there's no node to move to.
+type CursorOf[T any] struct {
+	// path from the root to the current node
+	path []*TreeOf[T]
+	// indexes[i] is the index of path[i+1] in path[i]'s subtrees
+	indexes []int
+}
+
+// Cursor returns a cursor pointing to t, which is its root.
+func (t *TreeOf[T]) Cursor() *CursorOf[T] {
+	return &CursorOf[T]{path: []*TreeOf[T]{t}}
+}
+
+// CursorTo returns a cursor pointing to node, with t as its root. Returns nil if
+// node isn't in t.
+func (t *TreeOf[T]) CursorTo(node *TreeOf[T]) *CursorOf[T] {
+	c := t.Cursor()
+	if !c.find(node) {
+		return nil
+	}
+	return c
+}
+
+// find moves to node, searching the current node's subtree in pre-order. It
+// returns false, without moving, if node isn't found.
+func (c *CursorOf[T]) find(node *TreeOf[T]) bool {
+	if c.Node() == node {
+		return true
+	}
+	for i := range c.Node().Subtrees {
+		c.GotoChild(i)
+		if c.find(node) {
+			return true
+		}
+		c.GotoParent()
+	}
+	return false
+}
+
+// Node returns the node the cursor points to.
+func (c *CursorOf[T]) Node() *TreeOf[T] {
+	return c.path[len(c.path)-1]
+}
+
+// Depth returns the depth of the current node. The root is at depth 0.
+func (c *CursorOf[T]) Depth() int {
+	return len(c.indexes)
+}
+
+// Index returns the index of the current node in its parent's subtrees. Returns
+// -1 for the root.
+func (c *CursorOf[T]) Index() int {
+	if len(c.indexes) == 0 {
+		return -1
+	}
+	return c.indexes[len(c.indexes)-1]
+}
+
+// Parent returns the parent of the current node, without moving. Returns nil for
+// the root.
+func (c *CursorOf[T]) Parent() *TreeOf[T] {
+	if len(c.path) < 2 {
+		return nil
+	}
+	return c.path[len(c.path)-2]
+}
+
+// Ancestors returns the ancestors of the current node, from its parent to the
+// root.
+func (c *CursorOf[T]) Ancestors() []*TreeOf[T] {
+	ancestors := make([]*TreeOf[T], 0, len(c.path)-1)
+	for i := len(c.path) - 2; i >= 0; i-- {
+		ancestors = append(ancestors, c.path[i])
+	}
+	return ancestors
+}
+
+// Copy returns a copy of the cursor, which moves independently.
+func (c *CursorOf[T]) Copy() *CursorOf[T] {
+	return &CursorOf[T]{
+		path:    append([]*TreeOf[T](nil), c.path...),
+		indexes: append([]int(nil), c.indexes...),
+	}
+}
+
+// GotoParent moves to the parent of the current node.
+func (c *CursorOf[T]) GotoParent() bool {
+	if len(c.indexes) == 0 {
+		return false
+	}
+	c.path = c.path[:len(c.path)-1]
+	c.indexes = c.indexes[:len(c.indexes)-1]
+	return true
+}
+
+// GotoChild moves to the ith subtree of the current node.
+func (c *CursorOf[T]) GotoChild(i int) bool {
+	subtrees := c.Node().Subtrees
+	if i < 0 || i >= len(subtrees) {
+		return false
+	}
+	c.path = append(c.path, subtrees[i])
+	c.indexes = append(c.indexes, i)
+	return true
+}
+
+// GotoFirstChild moves to the first subtree of the current node.
+func (c *CursorOf[T]) GotoFirstChild() bool {
+	return c.GotoChild(0)
+}
+
+// GotoLastChild moves to the last subtree of the current node.
+func (c *CursorOf[T]) GotoLastChild() bool {
+	return c.GotoChild(len(c.Node().Subtrees) - 1)
+}
+
+// GotoNextSibling moves to the next sibling of the current node.
+func (c *CursorOf[T]) GotoNextSibling() bool {
+	return c.gotoSibling(c.Index() + 1)
+}
+
+// GotoPrevSibling moves to the previous sibling of the current node.
+func (c *CursorOf[T]) GotoPrevSibling() bool {
+	return c.gotoSibling(c.Index() - 1)
+}
+
+func (c *CursorOf[T]) gotoSibling(i int) bool {
+	parent := c.Parent()
+	if parent == nil || i < 0 || i >= len(parent.Subtrees) {
+		return false
+	}
+	c.path[len(c.path)-1] = parent.Subtrees[i]
+	c.indexes[len(c.indexes)-1] = i
+	return true
+}
@@ -0,0 +1,154 @@
+package rd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTree returns:
+//
+//	Expr
+//	├─ Term
+//	│  └─ x
+//	├─ +
+//	└─ Term
+//	   ├─ (
+//	   ├─ Expr
+//	   │  └─ Term
+//	   │     └─ y
+//	   └─ )
+func testTree() *Tree {
+	return NewTree("Expr",
+		NewTree("Term", NewTree("x")),
+		NewTree("+"),
+		NewTree("Term", NewTree("("), NewTree("Expr", NewTree("Term", NewTree("y"))), NewTree(")")),
+	)
+}
+
+func symbols(trees []*Tree) (s []interface{}) {
+	for _, t := range trees {
+		s = append(s, t.Symbol)
+	}
+	return s
+}
+
+func TestWalk(t *testing.T) {
+	var visited []string
+	completed := testTree().Walk(func(t *Tree) WalkControl {
+		visited = append(visited, fmt.Sprint("pre ", t.Symbol))
+		switch t.Symbol {
+		case "(":
+			return SkipChildren
+		case "y":
+			return Stop
+		}
+		return Continue
+	}, func(t *Tree) WalkControl {
+		visited = append(visited, fmt.Sprint("post ", t.Symbol))
+		return Continue
+	})
+	assert.False(t, completed)
+	assert.Equal(t, []string{
+		"pre Expr", "pre Term", "pre x", "post x", "post Term", "pre +", "post +", "pre Term", "pre (", "post (",
+		"pre Expr", "pre Term", "pre y",
+	}, visited)
+
+	visited = nil
+	assert.True(t, testTree().Walk(nil, func(t *Tree) WalkControl {
+		if len(t.Subtrees) == 0 {
+			visited = append(visited, fmt.Sprint(t.Symbol))
+		}
+		return Continue
+	}))
+	assert.Equal(t, []string{"x", "+", "(", "y", ")"}, visited)
+}
+
+func TestSymbolVisitor(t *testing.T) {
+	depth, maxDepth := 0, 0
+	var terms []interface{}
+	v := SymbolVisitor[Token]{
+		OnVisit: map[interface{}]func(t *Tree) WalkControl{
+			"Expr": func(t *Tree) WalkControl {
+				if depth++; depth > maxDepth {
+					maxDepth = depth
+				}
+				return Continue
+			},
+			"Term": func(t *Tree) WalkControl {
+				terms = append(terms, t.Subtrees[0].Symbol)
+				return SkipChildren
+			},
+		},
+		OnLeave: map[interface{}]func(t *Tree) WalkControl{
+			"Expr": func(t *Tree) WalkControl {
+				depth--
+				return Continue
+			},
+		},
+	}
+	assert.True(t, testTree().Accept(v))
+	assert.Equal(t, []interface{}{"x", "("}, terms)
+	assert.Equal(t, 1, maxDepth)
+	assert.Equal(t, 0, depth)
+}
+
+func TestFind(t *testing.T) {
+	tree := testTree()
+	assert.Equal(t, tree.Subtrees[0], tree.FindSymbol("Term"))
+	assert.Nil(t, tree.FindSymbol("-"))
+	assert.Equal(t, []*Tree{tree.Subtrees[0], tree.Subtrees[2], tree.Subtrees[2].Subtrees[1].Subtrees[0]}, tree.FindAllSymbol("Term"))
+	assert.Equal(t, []interface{}{"Expr", "Term", "Expr"}, symbols(tree.FindAll(func(t *Tree) bool {
+		return len(t.Subtrees) > 1 || t.Symbol == "Expr"
+	})), "FindAll includes t")
+	assert.Equal(t, tree.Subtrees[2].Subtrees[1], tree.Find(func(t *Tree) bool {
+		return t.Symbol == "Expr" && len(t.Subtrees) == 1
+	}))
+
+	assert.Equal(t, []Token{"x", "+", "(", "y", ")"}, tree.Tokens())
+	assert.Equal(t, []interface{}{"x", "+", "(", "y", ")"}, symbols(tree.Leaves()))
+	assert.Equal(t, []interface{}{"x", "(", "Expr", ")"}, symbols(tree.AtDepth(2)))
+	assert.Equal(t, 4, tree.Height())
+	assert.Equal(t, 0, NewTree("x").Height())
+}
+
+func TestCursor(t *testing.T) {
+	tree := testTree()
+	c := tree.Cursor()
+	assert.Equal(t, -1, c.Index())
+	assert.False(t, c.GotoParent())
+	assert.False(t, c.GotoNextSibling())
+	assert.Nil(t, c.Parent())
+
+	assert.True(t, c.GotoLastChild())
+	assert.True(t, c.GotoChild(1))
+	assert.Equal(t, "Expr", c.Node().Symbol)
+	assert.Equal(t, 2, c.Depth())
+	assert.Equal(t, 1, c.Index())
+	assert.Equal(t, []interface{}{"Term", "Expr"}, symbols(c.Ancestors()))
+
+	d := c.Copy()
+	assert.True(t, d.GotoPrevSibling())
+	assert.False(t, d.GotoPrevSibling())
+	assert.Equal(t, "(", d.Node().Symbol)
+	assert.True(t, d.GotoNextSibling())
+	assert.True(t, d.GotoNextSibling())
+	assert.False(t, d.GotoNextSibling())
+	assert.Equal(t, ")", d.Node().Symbol)
+	assert.Equal(t, "Expr", c.Node().Symbol, "copies move independently")
+
+	assert.True(t, c.GotoFirstChild())
+	assert.True(t, c.GotoFirstChild())
+	assert.False(t, c.GotoFirstChild())
+	assert.Equal(t, "y", c.Node().Symbol)
+	assert.True(t, c.GotoParent())
+	assert.Equal(t, tree.Subtrees[2], c.Ancestors()[1])
+
+	y := tree.FindSymbol("y")
+	c = tree.CursorTo(y)
+	assert.Equal(t, y, c.Node())
+	assert.Equal(t, 4, c.Depth())
+	assert.Equal(t, []interface{}{"Term", "Expr", "Term", "Expr"}, symbols(c.Ancestors()))
+	assert.Nil(t, tree.CursorTo(NewTree("y")))
+}
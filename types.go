@@ -1,21 +1,93 @@
 package rd
 
-import "github.com/shivamMg/ppds/tree"
+import (
+	"fmt"
+
+	"github.com/shivamMg/ppds/tree"
+)
 
 // Token represents a token received after tokenization.
 type Token interface{}
 
+// Kinder is an optional interface for tokens that belong to a kind, ex.
+// "identifier" or "number". See Builder's MatchKind method.
+type Kinder interface {
+	Kind() string
+}
+
+// Label describes a class of tokens, ex. "identifier". It stands for the expected
+// token in the debug tree and in ParsingError's Expected, for matches that don't
+// expect a single token (see Builder's MatchFunc and MatchKind methods).
+type Label string
+
+func (l Label) String() string {
+	return "<" + string(l) + ">"
+}
+
+// Pos is a position in the source text.
+type Pos struct {
+	Offset int `json:"offset"` // byte offset, starting at 0
+	Line   int `json:"line"`   // line number, starting at 1
+	Column int `json:"column"` // column number, starting at 1
+}
+
+// IsValid reports whether the position is valid.
+func (p Pos) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Pos) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Positioner is an optional interface for tokens that know where they appear in
+// the source text. Builder uses it to fill source positions in parse tree Spans.
+type Positioner interface {
+	// Pos returns the position of the token's first character.
+	Pos() Pos
+	// End returns the position immediately after the token's last character.
+	End() Pos
+}
+
+// Span is the range of tokens covered by a parse tree node. StartPos and EndPos
+// are only valid if the tokens implement Positioner.
+type Span struct {
+	Start    int // index of the first token
+	End      int // index immediately after the last token
+	StartPos Pos // position of the first token's first character
+	EndPos   Pos // position immediately after the last token's last character
+}
+
 // Tree is a parse tree node. Symbol can either be a terminal (Token) or a non-terminal
 // (see Builder's Enter method). Tokens matched using Builder's Match method or added
 // using Builder's Add method, can be retrieved by type asserting Symbol.
-// Subtrees are child nodes of the current node.
-type Tree struct {
+// Subtrees are child nodes of the current node. Span is set by the Builder
+// for non-terminals that exit with a true result, and for added tokens.
+//
+// Tree is built by Builder. See TreeOf for trees built by BuilderOf.
+type Tree = TreeOf[Token]
+
+// TreeOf is a parse tree node built by BuilderOf[T]. It's like Tree, except that
+// terminals can be retrieved as tokens of type T using the Token method.
+type TreeOf[T any] struct {
 	Symbol   interface{}
-	Subtrees []*Tree
+	Subtrees []*TreeOf[T]
+	Span     Span
+	// Value is the value of a non-terminal, set by the Builder if semantic
+	// actions are used (see Actions).
+	Value  interface{}
+	valued bool
 }
 
 func NewTree(symbol interface{}, subtrees ...*Tree) *Tree {
-	t := Tree{Symbol: symbol}
+	return NewTreeOf[Token](symbol, subtrees...)
+}
+
+func NewTreeOf[T any](symbol interface{}, subtrees ...*TreeOf[T]) *TreeOf[T] {
+	t := TreeOf[T]{Symbol: symbol}
 	for _, subtree := range subtrees {
 		if subtree != nil {
 			t.Subtrees = append(t.Subtrees, subtree)
@@ -24,14 +96,22 @@ func NewTree(symbol interface{}, subtrees ...*Tree) *Tree {
 	return &t
 }
 
-func (t *Tree) Data() interface{} {
+// Token returns Symbol as a token of type T. ok is false if Symbol isn't of
+// type T, ex. for non-terminals. Note that for Tree, where T is the empty
+// interface Token, ok is true for any non-nil Symbol.
+func (t *TreeOf[T]) Token() (token T, ok bool) {
+	token, ok = t.Symbol.(T)
+	return
+}
+
+func (t *TreeOf[T]) Data() interface{} {
 	if t == nil {
 		return ""
 	}
 	return t.Symbol
 }
 
-func (t *Tree) Children() (c []tree.Node) {
+func (t *TreeOf[T]) Children() (c []tree.Node) {
 	for _, subtree := range t.Subtrees {
 		c = append(c, subtree)
 	}
@@ -39,12 +119,12 @@ func (t *Tree) Children() (c []tree.Node) {
 }
 
 // Add adds a subtree as a child to t.
-func (t *Tree) Add(subtree *Tree) {
+func (t *TreeOf[T]) Add(subtree *TreeOf[T]) {
 	t.Subtrees = append(t.Subtrees, subtree)
 }
 
 // Detach removes a subtree as a child of t.
-func (t *Tree) Detach(subtree *Tree) {
+func (t *TreeOf[T]) Detach(subtree *TreeOf[T]) {
 	for i, st := range t.Subtrees {
 		if st == subtree {
 			t.Subtrees = append(t.Subtrees[:i], t.Subtrees[i+1:]...)
@@ -53,39 +133,51 @@ func (t *Tree) Detach(subtree *Tree) {
 	}
 }
 
-func (t *Tree) String() string {
+func (t *TreeOf[T]) String() string {
 	return tree.SprintHrn(t)
 }
 
 // DebugTree is a debug tree node. Can be printed to help tracing the
 // parsing flow.
+//
+// Event is the event the node is for. A non-terminal's node has an ExitEvent
+// (an EnterEvent until it exits), and subtrees for events inside it: MatchEvents,
+// nodes for non-terminals entered, and BacktrackEvents for seeds grown (see
+// Memo). Non-terminals whose result is replayed from memory are printed with a
+// "<memo hit>" child.
 type DebugTree struct {
-	data     string
-	subtrees []*DebugTree
-}
-
-func newDebugTree(data string) *DebugTree {
-	return &DebugTree{
-		data:     data,
-		subtrees: []*DebugTree{},
-	}
+	Event    interface{}
+	Subtrees []*DebugTree
 }
 
 func (dt *DebugTree) add(subtree *DebugTree) {
-	dt.subtrees = append(dt.subtrees, subtree)
+	dt.Subtrees = append(dt.Subtrees, subtree)
 }
 
 func (dt *DebugTree) Data() interface{} {
-	return dt.data
+	return eventString(dt.Event)
 }
 
 func (dt *DebugTree) Children() (c []tree.Node) {
-	for _, child := range dt.subtrees {
+	for _, child := range dt.Subtrees {
 		c = append(c, child)
 	}
+	if e, ok := dt.Event.(ExitEvent); ok && e.MemoHit {
+		c = append(c, memoHitNode{})
+	}
 	return
 }
 
+type memoHitNode struct{}
+
+func (memoHitNode) Data() interface{} {
+	return "<memo hit>"
+}
+
+func (memoHitNode) Children() []tree.Node {
+	return nil
+}
+
 func (dt *DebugTree) String() string {
 	return tree.SprintHrn(dt)
 }